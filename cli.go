@@ -0,0 +1,365 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"richetechguy/internal/broker"
+	"richetechguy/internal/db"
+	"richetechguy/internal/game"
+	"richetechguy/internal/types"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: out [command]
+
+Commands:
+  serve                                     run the web server (default)
+  migrate                                   create or update the database tables
+  games list                                list saved games
+  games create -name NAME [-pack FILE]      create a game, optionally with its own questions
+  games clear -older-than DURATION          delete finished games older than DURATION (e.g. 720h)
+  players list -game ID                     list a game's players
+  questions import -file FILE [-replace]    add questions to questions.json
+  questions export [-o FILE]                write questions.json to FILE or stdout
+  results export -game ID [-format csv|json] [-o FILE]
+                                            write a game's standings and answers
+`
+
+// errUsage makes run print the usage text
+var errUsage = errors.New("unknown command")
+
+// run dispatches to a subcommand; no arguments starts the server so the
+// existing start command keeps working
+func run(args []string) error {
+	if len(args) == 0 {
+		serve()
+		return nil
+	}
+
+	var err error
+	switch args[0] {
+	case "serve":
+		serve()
+	case "migrate":
+		err = runMigrate()
+	case "games":
+		err = runSub(args[1:], map[string]func([]string) error{
+			"list":   runGamesList,
+			"create": runGamesCreate,
+			"clear":  runGamesClear,
+		})
+	case "players":
+		err = runSub(args[1:], map[string]func([]string) error{
+			"list": runPlayersList,
+		})
+	case "questions":
+		err = runSub(args[1:], map[string]func([]string) error{
+			"import": runQuestionsImport,
+			"export": runQuestionsExport,
+		})
+	case "results":
+		err = runSub(args[1:], map[string]func([]string) error{
+			"export": runResultsExport,
+		})
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		err = errUsage
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usage)
+	}
+	return err
+}
+
+func runSub(args []string, commands map[string]func([]string) error) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return errUsage
+	}
+	return command(args[1:])
+}
+
+// openGameManager loads the games from the database and, with BROKER=db,
+// shares changes with running servers. The returned func stops the broker.
+func openGameManager() (*game.GameManager, func(), error) {
+	gm, err := game.NewGameManager(os.Getenv("TURSO_DATABASE_URL"), os.Getenv("TURSO_AUTH_TOKEN"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if os.Getenv("BROKER") != "db" {
+		return gm, func() {}, nil
+	}
+
+	// BROKER=db shares games through the database so several instances can run behind a load balancer
+	interval, err := time.ParseDuration(os.Getenv("BROKER_POLL_INTERVAL"))
+	if err != nil {
+		interval = 250 * time.Millisecond
+	}
+	dbBroker, err := broker.NewDBBroker(gm.Db, interval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("starting database broker: %w", err)
+	}
+	gm.UseBroker(dbBroker)
+	return gm, func() { dbBroker.Close() }, nil
+}
+
+// openDB connects to the database without creating or changing anything,
+// for commands that only read
+func openDB() (*db.DB, error) {
+	return db.NewDB(os.Getenv("TURSO_DATABASE_URL"), os.Getenv("TURSO_AUTH_TOKEN"))
+}
+
+// loadGame reads one saved game for a read-only command
+func loadGame(database *db.DB, gameID string) (*types.GameState, error) {
+	g, err := database.LoadGame(gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("game not found: %s", gameID)
+	}
+	return g, err
+}
+
+func runMigrate() error {
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	if err := database.Initialize(); err != nil {
+		return err
+	}
+	fmt.Println("database is up to date")
+	return nil
+}
+
+func runGamesList(args []string) error {
+	fs := flag.NewFlagSet("games list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	saved, err := database.LoadGames()
+	if err != nil {
+		return err
+	}
+
+	games := make([]*types.GameState, 0, len(saved))
+	for _, g := range saved {
+		games = append(games, g)
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].ID < games[j].ID })
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tPLAYERS\tQUESTIONS")
+	for _, g := range games {
+		g.Mu.RLock()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", g.ID, g.Name, gameStatus(g), len(g.Players), len(g.Questions))
+		g.Mu.RUnlock()
+	}
+	return tw.Flush()
+}
+
+// gameStatus describes a game; callers hold g.Mu
+func gameStatus(g *types.GameState) string {
+	switch {
+	case g.IsActive && g.IsPaused:
+		return "paused"
+	case g.IsActive:
+		return "active"
+	case !g.EndTime.IsZero():
+		return "ended"
+	default:
+		return "waiting"
+	}
+}
+
+func runGamesCreate(args []string) error {
+	fs := flag.NewFlagSet("games create", flag.ContinueOnError)
+	name := fs.String("name", "", "game name")
+	pack := fs.String("pack", "", "JSON question pack for this game (defaults to questions.json at start)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("games create: -name is required")
+	}
+
+	// Check the pack before creating anything
+	var questions []types.Question
+	var rounds []types.Round
+	if *pack != "" {
+		var err error
+		if questions, rounds, err = game.LoadPack(*pack); err != nil {
+			return err
+		}
+	}
+
+	gm, closeGames, err := openGameManager()
+	if err != nil {
+		return err
+	}
+	defer closeGames()
+
+	g, err := gm.CreateGame(*name)
+	if err != nil {
+		return err
+	}
+	if questions != nil {
+		if err := gm.SetQuestions(g.ID, questions, rounds); err != nil {
+			return err
+		}
+	}
+	fmt.Println(g.ID)
+	return nil
+}
+
+func runGamesClear(args []string) error {
+	fs := flag.NewFlagSet("games clear", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", 0, "delete games created longer ago than this")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *olderThan <= 0 {
+		return errors.New("games clear: -older-than must be a positive duration")
+	}
+
+	gm, closeGames, err := openGameManager()
+	if err != nil {
+		return err
+	}
+	defer closeGames()
+
+	deleted, err := gm.DeleteGamesBefore(time.Now().Add(-*olderThan))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("deleted %d games\n", deleted)
+	return nil
+}
+
+func runPlayersList(args []string) error {
+	fs := flag.NewFlagSet("players list", flag.ContinueOnError)
+	gameID := fs.String("game", "", "game ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *gameID == "" {
+		return errors.New("players list: -game is required")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	g, err := loadGame(database, *gameID)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tID\tNAME\tSCORE")
+	for _, standing := range game.Standings(g) {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n", standing.Rank, standing.PlayerID, standing.Name, standing.Score)
+	}
+	return tw.Flush()
+}
+
+func runQuestionsImport(args []string) error {
+	fs := flag.NewFlagSet("questions import", flag.ContinueOnError)
+	file := fs.String("file", "", "JSON file of questions")
+	replace := fs.Bool("replace", false, "replace the question bank instead of appending")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("questions import: -file is required")
+	}
+
+	// Rounds don't carry over into the shared bank, only their questions
+	questions, _, err := game.LoadPack(*file)
+	if err != nil {
+		return err
+	}
+	qm := game.NewQuestionManager()
+	if err := qm.LoadQuestions(); err != nil {
+		return err
+	}
+	if err := qm.Import(questions, *replace); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d questions, %d in total\n", len(questions), len(qm.GetQuestions()))
+	return nil
+}
+
+func runQuestionsExport(args []string) error {
+	fs := flag.NewFlagSet("questions export", flag.ContinueOnError)
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	qm := game.NewQuestionManager()
+	if err := qm.LoadQuestions(); err != nil {
+		return err
+	}
+	return writeOutput(*out, qm.Export)
+}
+
+func runResultsExport(args []string) error {
+	fs := flag.NewFlagSet("results export", flag.ContinueOnError)
+	gameID := fs.String("game", "", "game ID")
+	format := fs.String("format", "csv", "csv or json")
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *gameID == "" {
+		return errors.New("results export: -game is required")
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("results export: unknown format %q", *format)
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	g, err := loadGame(database, *gameID)
+	if err != nil {
+		return err
+	}
+
+	results := game.BuildResults(g)
+	if *format == "json" {
+		return writeOutput(*out, results.WriteJSON)
+	}
+	return writeOutput(*out, results.WriteCSV)
+}
+
+// writeOutput sends write's output to path, or stdout when path is empty
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
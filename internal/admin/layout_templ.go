@@ -42,7 +42,7 @@ func AdminLayout(title string) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</title><script src=\"https://unpkg.com/htmx.org@1.9.6\"></script><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"/static/js/admin.js\"></script><style>\n\t\t@keyframes slide-in {\n\t\t\tfrom {\n\t\t\t\topacity: 0;\n\t\t\t\ttransform: translateY(-10px);\n\t\t\t}\n\n\t\t\tto {\n\t\t\t\topacity: 1;\n\t\t\t\ttransform: translateY(0);\n\t\t\t}\n\t\t}\n\n\t\t.animate-slide-in {\n\t\t\tanimation: slide-in 0.3s ease-out forwards;\n\t\t}\n\n\t\t.player-enter {\n\t\t\topacity: 0;\n\t\t\ttransform: translateY(-10px);\n\t\t}\n\n\t\t.player-enter-active {\n\t\t\topacity: 1;\n\t\t\ttransform: translateY(0);\n\t\t\ttransition: opacity 300ms, transform 300ms;\n\t\t}\n\n\t\t.player-exit {\n\t\t\topacity: 1;\n\t\t}\n\n\t\t.player-exit-active {\n\t\t\topacity: 0;\n\t\t\ttransform: translateY(-10px);\n\t\t\ttransition: opacity 300ms, transform 300ms;\n\t\t}\n\t</style></head><body><div class=\"min-h-screen bg-gray-100\"><nav class=\"bg-white shadow-lg\"><div class=\"max-w-7xl mx-auto px-4\"><div class=\"flex justify-between h-16\"><div class=\"flex\"><div class=\"flex-shrink-0 flex items-center\"><span class=\"text-xl font-bold\">Admin Dashboard</span></div></div></div></div></nav>")
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</title><script src=\"https://unpkg.com/htmx.org@1.9.6\"></script><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"/static/js/admin.js\"></script><style>\n\t\t@keyframes slide-in {\n\t\t\tfrom {\n\t\t\t\topacity: 0;\n\t\t\t\ttransform: translateY(-10px);\n\t\t\t}\n\n\t\t\tto {\n\t\t\t\topacity: 1;\n\t\t\t\ttransform: translateY(0);\n\t\t\t}\n\t\t}\n\n\t\t.animate-slide-in {\n\t\t\tanimation: slide-in 0.3s ease-out forwards;\n\t\t}\n\n\t\t.player-enter {\n\t\t\topacity: 0;\n\t\t\ttransform: translateY(-10px);\n\t\t}\n\n\t\t.player-enter-active {\n\t\t\topacity: 1;\n\t\t\ttransform: translateY(0);\n\t\t\ttransition: opacity 300ms, transform 300ms;\n\t\t}\n\n\t\t.player-exit {\n\t\t\topacity: 1;\n\t\t}\n\n\t\t.player-exit-active {\n\t\t\topacity: 0;\n\t\t\ttransform: translateY(-10px);\n\t\t\ttransition: opacity 300ms, transform 300ms;\n\t\t}\n\t</style></head><body><div class=\"min-h-screen bg-gray-100\"><nav class=\"bg-white shadow-lg print:hidden\"><div class=\"max-w-7xl mx-auto px-4\"><div class=\"flex justify-between h-16\"><div class=\"flex\"><div class=\"flex-shrink-0 flex items-center\"><span class=\"text-xl font-bold\">Admin Dashboard</span></div></div></div></div></nav>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,181 @@
+// Package api serves the versioned JSON API under /api/v1. It sits alongside
+// the htmx admin and drives the same GameManager.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"richetechguy/internal/game"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/ratelimit"
+	"strconv"
+	"strings"
+)
+
+// Prefix is where the API is mounted
+const Prefix = "/api/v1"
+
+// maxBodyBytes caps request bodies; API requests are small JSON documents
+const maxBodyBytes = 64 << 10
+
+// Deps are the services the API handlers use
+type Deps struct {
+	Games     *game.GameManager
+	Questions *game.QuestionManager
+	Limits    *ratelimit.Limits
+	// AdminToken guards questions and every write except joining a game.
+	// Empty refuses those requests.
+	AdminToken string
+}
+
+// Register adds every API route to mux
+func Register(mux *http.ServeMux, d Deps) {
+	mux.HandleFunc("GET "+Prefix+"/openapi.yaml", handleOpenAPI)
+
+	mux.HandleFunc("GET "+Prefix+"/games", handleListGames(d))
+	mux.HandleFunc("POST "+Prefix+"/games", requireAdmin(d, handleCreateGame(d)))
+	mux.HandleFunc("GET "+Prefix+"/games/{gameID}", handleGetGame(d))
+	mux.HandleFunc("POST "+Prefix+"/games/{gameID}/start", requireAdmin(d, handleStartGame(d)))
+	mux.HandleFunc("POST "+Prefix+"/games/{gameID}/end", requireAdmin(d, handleEndGame(d)))
+	mux.HandleFunc("GET "+Prefix+"/games/{gameID}/results", handleGameResults(d))
+	mux.HandleFunc("GET "+Prefix+"/games/{gameID}/leaderboard", handleLeaderboard(d))
+
+	// A player's ID is enough to play as them, so only admins see everyone's
+	mux.HandleFunc("GET "+Prefix+"/games/{gameID}/players", requireAdmin(d, handleListPlayers(d)))
+	mux.HandleFunc("POST "+Prefix+"/games/{gameID}/players", handleJoinGame(d))
+	mux.HandleFunc("GET "+Prefix+"/games/{gameID}/players/{playerID}", requireAdmin(d, handleGetPlayer(d)))
+	mux.HandleFunc("GET "+Prefix+"/games/{gameID}/players/{playerID}/score", requireAdmin(d, handleScoreBreakdown(d)))
+
+	mux.HandleFunc("GET "+Prefix+"/questions", requireAdmin(d, handleListQuestions(d)))
+	mux.HandleFunc("POST "+Prefix+"/questions", requireAdmin(d, handleAddQuestion(d)))
+
+	// Anything else under the prefix gets a JSON 404 rather than the join page
+	mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "no such endpoint")
+	})
+}
+
+// Error codes returned in error objects
+const (
+	CodeBadRequest   = "bad_request"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeRateLimited  = "rate_limited"
+	CodeInternal     = "internal"
+)
+
+// Error is the body of every non-2xx response
+type Error struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail says what went wrong; RequestID matches the server's logs
+type ErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	// Suggestions are free names to try when a join's name was taken
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, Error{Error: ErrorDetail{
+		Code:      code,
+		Message:   message,
+		RequestID: w.Header().Get(middleware.RequestIDHeader),
+	}})
+}
+
+// decodeBody reads a JSON request body into v, rejecting unknown fields
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// requireAdmin rejects requests without the admin bearer token, and every
+// request when no token is configured
+func requireAdmin(d Deps, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if d.AdminToken == "" {
+			writeError(w, http.StatusForbidden, CodeForbidden, "admin requests are disabled until ADMIN_TOKEN is set")
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(d.AdminToken), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="trivia"`)
+			writeError(w, http.StatusUnauthorized, CodeUnauthorized, "a valid admin bearer token is required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Page is the envelope for list responses
+type Page[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Pagination describes where a page sits in the full list
+type Pagination struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	Total      int  `json:"total"`
+	NextOffset *int `json:"nextOffset,omitempty"` // absent on the last page
+}
+
+// paginate slices items using the limit and offset query parameters
+func paginate[T any](w http.ResponseWriter, r *http.Request, items []T) (Page[T], bool) {
+	limit, offset := defaultPageSize, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			writeError(w, http.StatusBadRequest, CodeBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+			return Page[T]{}, false
+		}
+		limit = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, CodeBadRequest, "offset must be zero or more")
+			return Page[T]{}, false
+		}
+		offset = n
+	}
+
+	page := Page[T]{
+		Data:       []T{},
+		Pagination: Pagination{Limit: limit, Offset: offset, Total: len(items)},
+	}
+	if offset < len(items) {
+		end := min(offset+limit, len(items))
+		page.Data = items[offset:end]
+		if end < len(items) {
+			page.Pagination.NextOffset = &end
+		}
+	}
+	return page, true
+}
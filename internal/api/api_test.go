@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"richetechguy/internal/game"
+	"richetechguy/internal/ratelimit"
+	"richetechguy/internal/types"
+	"strings"
+	"testing"
+)
+
+// testDeps serves one waiting game, "g1", with a player "p1" who has answered
+// its first question
+func testDeps(adminToken string) Deps {
+	g := game.NewGameState("Quiz night")
+	g.ID = "g1"
+	g.Questions = []types.Question{{ID: 1, Type: types.SingleChoice, Text: "One?", Options: []string{"a", "b"}, Correct: "1"}}
+	g.Players["p1"] = &types.Player{ID: "p1", Name: "Pat", Answers: map[int]string{1: "2"}}
+	return Deps{
+		Games:      &game.GameManager{Games: map[string]*types.GameState{g.ID: g}},
+		Questions:  game.NewQuestionManager(),
+		Limits:     ratelimit.NewLimits(ratelimit.DefaultConfig()),
+		AdminToken: adminToken,
+	}
+}
+
+func TestAPI(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		admin    bool // send the admin bearer token
+		disabled bool // run the server without an admin token
+		want     int
+		wantCode string // the error code, for errors
+		check    func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name: "list games", method: "GET", path: "/api/v1/games", want: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var page Page[Game]
+				decode(t, w, &page)
+				if page.Pagination.Total != 1 || page.Data[0].Status != "waiting" || page.Data[0].PlayerCount != 1 {
+					t.Errorf("got %+v, want the one waiting game with its player", page)
+				}
+			},
+		},
+		{name: "bad limit", method: "GET", path: "/api/v1/games?limit=0", want: http.StatusBadRequest, wantCode: CodeBadRequest},
+		{name: "unknown game", method: "GET", path: "/api/v1/games/nope", want: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "unknown endpoint", method: "GET", path: "/api/v1/nothing", want: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "start without the token", method: "POST", path: "/api/v1/games/g1/start", want: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{
+			name: "admin disabled", method: "GET", path: "/api/v1/games/g1/players", admin: true, disabled: true,
+			want: http.StatusForbidden, wantCode: CodeForbidden,
+		},
+		{name: "players without the token", method: "GET", path: "/api/v1/games/g1/players", want: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{name: "player without the token", method: "GET", path: "/api/v1/games/g1/players/p1", want: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{
+			name: "players", method: "GET", path: "/api/v1/games/g1/players", admin: true, want: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var page Page[Player]
+				decode(t, w, &page)
+				if len(page.Data) != 1 || page.Data[0].ID != "p1" {
+					t.Errorf("got %+v, want player p1", page.Data)
+				}
+				if strings.Contains(w.Body.String(), "answers") {
+					t.Error("player list includes answers")
+				}
+			},
+		},
+		{name: "unknown player", method: "GET", path: "/api/v1/games/g1/players/nobody", admin: true, want: http.StatusNotFound, wantCode: CodeNotFound},
+		{
+			name: "join", method: "POST", path: "/api/v1/games/g1/players", body: `{"name": "  Sam  "}`, want: http.StatusCreated,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp JoinGameResponse
+				decode(t, w, &resp)
+				if resp.Player.Name != "Sam" || resp.Player.ID == "" {
+					t.Errorf("joined as %+v, want Sam with an ID", resp.Player)
+				}
+				if want := "/api/v1/games/g1/players/" + resp.Player.ID; w.Header().Get("Location") != want {
+					t.Errorf("Location = %q, want %q", w.Header().Get("Location"), want)
+				}
+				if !strings.Contains(resp.SocketURL, "playerId="+resp.Player.ID) {
+					t.Errorf("socket URL %q doesn't name the player", resp.SocketURL)
+				}
+			},
+		},
+		{name: "join without a name", method: "POST", path: "/api/v1/games/g1/players", body: `{"name": " "}`, want: http.StatusBadRequest, wantCode: CodeBadRequest},
+		{name: "join with a taken name", method: "POST", path: "/api/v1/games/g1/players", body: `{"name": "pat"}`, want: http.StatusConflict, wantCode: CodeConflict},
+		{name: "unknown field", method: "POST", path: "/api/v1/games/g1/players", body: `{"nick": "Sam"}`, want: http.StatusBadRequest, wantCode: CodeBadRequest},
+		{
+			name: "results mid-game", method: "GET", path: "/api/v1/games/g1/results", want: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var results Results
+				decode(t, w, &results)
+				if len(results.Standings) != 1 || len(results.Answers) != 0 {
+					t.Errorf("got %d standings and answers %+v, want the standings without answers", len(results.Standings), results.Answers)
+				}
+			},
+		},
+		{
+			name: "results mid-game for the admin", method: "GET", path: "/api/v1/games/g1/results", admin: true, want: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var results Results
+				decode(t, w, &results)
+				if len(results.Answers) != 1 || results.Answers[0].Answers[0].Correct != nil {
+					t.Errorf("got answers %+v, want p1's answer, unmarked until the game ends", results.Answers)
+				}
+			},
+		},
+		{
+			name: "leaderboard around a player", method: "GET", path: "/api/v1/games/g1/leaderboard?around=p1", want: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var board Leaderboard
+				decode(t, w, &board)
+				if len(board.Rankings) != 1 || board.Rankings[0].Rank != 1 {
+					t.Errorf("got %+v, want p1 first", board.Rankings)
+				}
+			},
+		},
+		{name: "leaderboard around nobody", method: "GET", path: "/api/v1/games/g1/leaderboard?around=nobody", want: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "questions without the token", method: "GET", path: "/api/v1/questions", want: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{
+			name: "question with one option", method: "POST", path: "/api/v1/questions", admin: true,
+			body: `{"text": "Only?", "options": ["a"], "correct": "1"}`, want: http.StatusBadRequest, wantCode: CodeBadRequest,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				if !strings.Contains(w.Body.String(), "at least two options") {
+					t.Errorf("error %s doesn't say what's required", w.Body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminToken := "secret"
+			if tt.disabled {
+				adminToken = ""
+			}
+			mux := http.NewServeMux()
+			Register(mux, testDeps(adminToken))
+
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.admin {
+				r.Header.Set("Authorization", "Bearer secret")
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.wantCode != "" {
+				var e Error
+				decode(t, w, &e)
+				if e.Error.Code != tt.wantCode {
+					t.Errorf("error code = %q, want %q", e.Error.Code, tt.wantCode)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, w)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+}
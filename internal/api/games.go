@@ -0,0 +1,309 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"richetechguy/internal/game"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/types"
+	"richetechguy/internal/websocket"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Game is the API view of a game. Question answers are never included.
+type Game struct {
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	Status          string        `json:"status"` // waiting, active, paused or ended
+	Round           int           `json:"round"`
+	QuestionCount   int           `json:"questionCount"`
+	PlayerCount     int           `json:"playerCount"`
+	SpectatorCount  int           `json:"spectatorCount"`
+	CurrentQuestion *int          `json:"currentQuestionId,omitempty"`
+	StartTime       *time.Time    `json:"startTime,omitempty"`
+	EndTime         *time.Time    `json:"endTime,omitempty"`
+	Theme           types.Theme   `json:"theme"` // as set by the admin, without defaults
+	LateJoin        string        `json:"lateJoin"`
+	Rounds          []types.Round `json:"rounds,omitempty"`
+	// CurrentRound indexes Rounds while one of its questions is open
+	CurrentRound *int `json:"currentRound,omitempty"`
+}
+
+func gameView(g *types.GameState) Game {
+	g.Mu.RLock()
+	defer g.Mu.RUnlock()
+
+	view := Game{
+		ID:             g.ID,
+		Name:           g.Name,
+		Round:          g.Round,
+		QuestionCount:  len(g.Questions),
+		PlayerCount:    len(g.Players),
+		SpectatorCount: g.Spectators,
+		Theme:          g.Theme,
+		LateJoin:       string(g.LateJoin),
+		Rounds:         g.Rounds,
+	}
+	if view.LateJoin == "" {
+		view.LateJoin = string(types.LateJoinDisallow)
+	}
+	switch {
+	case !g.EndTime.IsZero():
+		view.Status = "ended"
+	case g.IsActive && g.IsPaused:
+		view.Status = "paused"
+	case g.IsActive:
+		view.Status = "active"
+	default:
+		view.Status = "waiting"
+	}
+	if g.CurrentQuestion != nil {
+		id := g.CurrentQuestion.ID
+		view.CurrentQuestion = &id
+		if round := g.RoundOf(id); round >= 0 {
+			view.CurrentRound = &round
+		}
+	}
+	if !g.StartTime.IsZero() {
+		start := g.StartTime
+		view.StartTime = &start
+	}
+	if !g.EndTime.IsZero() {
+		end := g.EndTime
+		view.EndTime = &end
+	}
+	return view
+}
+
+// lookupGame writes a 404 and returns nil when the path's game doesn't exist
+func lookupGame(d Deps, w http.ResponseWriter, r *http.Request) *types.GameState {
+	g, err := d.Games.GetGame(r.PathValue("gameID"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, "game not found")
+		return nil
+	}
+	middleware.Annotate(r.Context(), "game_id", g.ID)
+	return g
+}
+
+func handleListGames(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		games := make([]Game, 0)
+		for _, g := range d.Games.GetAllGames() {
+			view := gameView(g)
+			if status != "" && view.Status != status {
+				continue
+			}
+			games = append(games, view)
+		}
+		// Game IDs embed their creation time, so this is newest first
+		sort.Slice(games, func(i, j int) bool { return games[i].ID > games[j].ID })
+
+		if page, ok := paginate(w, r, games); ok {
+			writeJSON(w, http.StatusOK, page)
+		}
+	}
+}
+
+// CreateGameRequest is the body of POST /games
+type CreateGameRequest struct {
+	Name     string `json:"name"`
+	LateJoin string `json:"lateJoin"` // disallow (the default), zero or median
+	// Rounds replace the shared question bank with the rounds' own questions
+	Rounds []game.RoundPack `json:"rounds"`
+}
+
+func handleCreateGame(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateGameRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if req.Name == "" {
+			req.Name = game.DefaultGameName
+		}
+		lateJoin, err := game.ParseLateJoinPolicy(req.LateJoin)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+			return
+		}
+		var questions []types.Question
+		var rounds []types.Round
+		if len(req.Rounds) > 0 {
+			if questions, rounds, err = game.BuildRounds(req.Rounds); err != nil {
+				writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+				return
+			}
+		}
+		g, err := d.Games.CreateGame(req.Name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+			return
+		}
+		if len(rounds) > 0 {
+			if err := d.Games.SetQuestions(g.ID, questions, rounds); err != nil {
+				writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+				return
+			}
+		}
+		if lateJoin != types.LateJoinDisallow {
+			if g, err = d.Games.SetLateJoinPolicy(g.ID, lateJoin); err != nil {
+				writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+				return
+			}
+		}
+		w.Header().Set("Location", Prefix+"/games/"+g.ID)
+		writeJSON(w, http.StatusCreated, gameView(g))
+	}
+}
+
+func handleGetGame(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if g := lookupGame(d, w, r); g != nil {
+			writeJSON(w, http.StatusOK, gameView(g))
+		}
+	}
+}
+
+func handleStartGame(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		if err := d.Games.StartGame(g.ID, d.Questions); err != nil {
+			writeError(w, http.StatusConflict, CodeConflict, err.Error())
+			return
+		}
+		websocket.AnnounceGameStarted(g)
+		writeJSON(w, http.StatusOK, gameView(g))
+	}
+}
+
+func handleEndGame(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		if err := d.Games.EndGame(g.ID); errors.Is(err, types.ErrGameEnded) {
+			writeError(w, http.StatusConflict, CodeConflict, err.Error())
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+			return
+		}
+		websocket.PushViews(g)
+		writeJSON(w, http.StatusOK, gameView(g))
+	}
+}
+
+// Results are a game's standings and what each player answered. Answers are
+// left out until the game ends, unless an admin asks.
+type Results struct {
+	Game      Game            `json:"game"`
+	Standings []game.Standing `json:"standings"`
+	Answers   []PlayerAnswers `json:"answers"`
+}
+
+// PlayerAnswers lists one player's answers by question
+type PlayerAnswers struct {
+	PlayerID string       `json:"playerId"`
+	Answers  []AnswerView `json:"answers"`
+	// RoundScores are the points from each of the game's rounds, in order
+	RoundScores []int `json:"roundScores,omitempty"`
+}
+
+// AnswerView is a single answer and, once the game has ended, whether it was right
+type AnswerView struct {
+	QuestionID int    `json:"questionId"`
+	Answer     string `json:"answer"`
+	Correct    *bool  `json:"correct,omitempty"`
+}
+
+func handleGameResults(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		view := gameView(g)
+		results := Results{Game: view, Standings: game.Standings(g), Answers: []PlayerAnswers{}}
+		// Anyone could read the answers off a game in progress
+		if view.Status != "ended" && !middleware.IsAdmin(r, d.AdminToken) {
+			writeJSON(w, http.StatusOK, results)
+			return
+		}
+
+		g.Mu.RLock()
+		questions := make(map[int]types.Question, len(g.Questions))
+		for _, q := range g.Questions {
+			questions[q.ID] = q
+		}
+		for _, standing := range results.Standings {
+			player, ok := g.Players[standing.PlayerID]
+			if !ok {
+				continue
+			}
+			answers := PlayerAnswers{PlayerID: player.ID, Answers: []AnswerView{}, RoundScores: game.RoundScores(g, player)}
+			for questionID, answer := range player.GetAllAnswers() {
+				answerView := AnswerView{QuestionID: questionID, Answer: answer}
+				// Marking answers mid-game would give the correct ones away
+				if q, ok := questions[questionID]; ok && view.Status == "ended" {
+					correct := player.IsRight(&q)
+					answerView.Correct = &correct
+				}
+				answers.Answers = append(answers.Answers, answerView)
+			}
+			sort.Slice(answers.Answers, func(i, j int) bool {
+				return answers.Answers[i].QuestionID < answers.Answers[j].QuestionID
+			})
+			results.Answers = append(results.Answers, answers)
+		}
+		g.Mu.RUnlock()
+
+		writeJSON(w, http.StatusOK, results)
+	}
+}
+
+// aroundReach is how many places either side of a player ?around= returns
+const aroundReach = 2
+
+// Leaderboard is a slice of a game's rankings
+type Leaderboard struct {
+	Rankings []game.Ranking `json:"rankings"`
+}
+
+// handleLeaderboard returns the top of the table, or with ?around= the
+// places either side of one player
+func handleLeaderboard(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		top := defaultPageSize
+		if v := r.URL.Query().Get("top"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxPageSize {
+				writeError(w, http.StatusBadRequest, CodeBadRequest, "top must be between 1 and "+strconv.Itoa(maxPageSize))
+				return
+			}
+			top = n
+		}
+
+		leaderboard := game.BuildLeaderboard(g)
+		rankings := leaderboard.Top(top)
+		if playerID := r.URL.Query().Get("around"); playerID != "" {
+			if _, ok := leaderboard.Find(playerID); !ok {
+				writeError(w, http.StatusNotFound, CodeNotFound, "player not found")
+				return
+			}
+			rankings = leaderboard.Around(playerID, aroundReach)
+		}
+		writeJSON(w, http.StatusOK, Leaderboard{Rankings: append([]game.Ranking{}, rankings...)})
+	}
+}
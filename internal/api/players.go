@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"richetechguy/internal/game"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Player is the API view of a player. Answers are only exposed through results.
+type Player struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	Score     int                  `json:"score"`
+	Status    types.PresenceStatus `json:"status"`
+	LastSeen  time.Time            `json:"lastSeen"`
+	Anonymous bool                 `json:"anonymous,omitempty"` // joined without a name
+}
+
+func playerView(p *types.Player) Player {
+	status, lastSeen := p.Presence()
+	return Player{ID: p.ID, Name: p.Name, Score: p.Score, Status: status, LastSeen: lastSeen, Anonymous: p.Anonymous}
+}
+
+func handleListPlayers(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		g.Mu.RLock()
+		players := make([]Player, 0, len(g.Players))
+		for _, p := range g.Players {
+			players = append(players, playerView(p))
+		}
+		g.Mu.RUnlock()
+		sort.Slice(players, func(i, j int) bool { return players[i].ID < players[j].ID })
+
+		if page, ok := paginate(w, r, players); ok {
+			writeJSON(w, http.StatusOK, page)
+		}
+	}
+}
+
+func handleGetPlayer(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		g.Mu.RLock()
+		p, ok := g.Players[r.PathValue("playerID")]
+		g.Mu.RUnlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, CodeNotFound, "player not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, playerView(p))
+	}
+}
+
+// handleScoreBreakdown explains a player's score, question by question
+func handleScoreBreakdown(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		breakdown, err := game.BuildBreakdown(g, r.PathValue("playerID"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, breakdown)
+	}
+}
+
+// JoinGameRequest is the body of POST /games/{gameID}/players
+type JoinGameRequest struct {
+	Name string `json:"name"`
+}
+
+// JoinGameResponse tells the new player how to connect
+type JoinGameResponse struct {
+	Player Player `json:"player"`
+	// SocketURL is the path to open a WebSocket on, or an event stream with /sse/game
+	SocketURL string `json:"socketUrl"`
+}
+
+func handleJoinGame(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := d.Limits.ClientIP(r)
+		if !d.Limits.Joins.Allow("join:" + ip) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Limits.Joins.RetryAfter().Seconds()))))
+			writeError(w, http.StatusTooManyRequests, CodeRateLimited, "too many join attempts, try again shortly")
+			return
+		}
+		g := lookupGame(d, w, r)
+		if g == nil {
+			return
+		}
+		var req JoinGameRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			writeError(w, http.StatusBadRequest, CodeBadRequest, "name is required")
+			return
+		}
+
+		playerID, err := d.Games.AddPlayer(g.ID, req.Name, ip)
+		if errors.Is(err, types.ErrGameFull) {
+			writeError(w, http.StatusConflict, CodeConflict, "this game is full")
+			return
+		}
+		if errors.Is(err, types.ErrBanned) {
+			writeError(w, http.StatusForbidden, CodeForbidden, err.Error())
+			return
+		}
+		var nameErr *game.NameError
+		if errors.As(err, &nameErr) {
+			status, code := http.StatusBadRequest, CodeBadRequest
+			if nameErr.Taken {
+				status, code = http.StatusConflict, CodeConflict
+			}
+			writeJSON(w, status, Error{Error: ErrorDetail{
+				Code:        code,
+				Message:     nameErr.Reason,
+				RequestID:   w.Header().Get(middleware.RequestIDHeader),
+				Suggestions: nameErr.Suggestions,
+			}})
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusConflict, CodeConflict, err.Error())
+			return
+		}
+		middleware.Annotate(r.Context(), "player_id", playerID)
+
+		g.Mu.RLock()
+		p := g.Players[playerID]
+		g.Mu.RUnlock()
+		w.Header().Set("Location", Prefix+"/games/"+g.ID+"/players/"+playerID)
+		writeJSON(w, http.StatusCreated, JoinGameResponse{
+			Player:    playerView(p),
+			SocketURL: "/ws/game?gameId=" + g.ID + "&playerId=" + playerID,
+		})
+	}
+}
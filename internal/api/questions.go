@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"richetechguy/internal/game"
+	"richetechguy/internal/types"
+)
+
+func handleListQuestions(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		questions := append([]types.Question{}, d.Questions.GetQuestions()...)
+		if page, ok := paginate(w, r, questions); ok {
+			writeJSON(w, http.StatusOK, page)
+		}
+	}
+}
+
+// AddQuestionRequest is the body of POST /questions
+type AddQuestionRequest struct {
+	Text    string             `json:"text"`
+	Options []string           `json:"options"`
+	Type    types.QuestionType `json:"type,omitempty"`
+	Correct string             `json:"correct"`
+	// TimeLimit and Media are optional, as on types.Question
+	TimeLimit int    `json:"timeLimit,omitempty"`
+	Media     string `json:"media,omitempty"`
+}
+
+func handleAddQuestion(d Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddQuestionRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if req.Type == "" {
+			req.Type = types.SingleChoice
+		}
+		q := types.Question{Text: req.Text, Options: req.Options, Type: req.Type, Correct: req.Correct,
+			TimeLimit: req.TimeLimit, Media: req.Media}
+		if err := game.ValidateQuestion(q); err != nil {
+			writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+			return
+		}
+		if err := d.Questions.AddQuestion(q); err != nil {
+			writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+			return
+		}
+		questions := d.Questions.GetQuestions()
+		writeJSON(w, http.StatusCreated, questions[len(questions)-1])
+	}
+}
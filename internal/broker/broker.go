@@ -0,0 +1,102 @@
+package broker
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Topics shared by every server instance
+const (
+	TopicGames   = "games"   // replicated game snapshots
+	TopicPlayers = "players" // messages for the players of a game
+	TopicAdmins  = "admins"  // messages for every admin dashboard
+)
+
+// Event is a message published on a topic. Origin is the instance that
+// published it, so subscribers can skip their own state updates.
+type Event struct {
+	ID        int64
+	Topic     string
+	Origin    string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Handler receives events for a topic it subscribed to
+type Handler func(event Event)
+
+// Broker fans events out to every server instance sharing a game. Handlers are
+// called for events from all instances, including the one that published them.
+type Broker interface {
+	InstanceID() string
+	Publish(topic string, payload []byte) error
+	Subscribe(topic string, handler Handler)
+	Close() error
+}
+
+// NewInstanceID names this process for event origins
+func NewInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "instance"
+	}
+	return fmt.Sprintf("%s-%d", host, time.Now().UnixNano())
+}
+
+// subscribers is the handler registry shared by the broker implementations
+type subscribers struct {
+	handlers map[string][]Handler
+	mu       sync.RWMutex
+}
+
+func (s *subscribers) Subscribe(topic string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handlers == nil {
+		s.handlers = make(map[string][]Handler)
+	}
+	s.handlers[topic] = append(s.handlers[topic], handler)
+}
+
+func (s *subscribers) deliver(event Event) {
+	s.mu.RLock()
+	handlers := s.handlers[event.Topic]
+	s.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// MemoryBroker delivers events within a single process. It is the default
+// when only one server instance is running.
+type MemoryBroker struct {
+	subscribers
+	instanceID string
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{instanceID: NewInstanceID()}
+}
+
+func (b *MemoryBroker) InstanceID() string {
+	return b.instanceID
+}
+
+// Publish delivers the event to local subscribers before returning
+func (b *MemoryBroker) Publish(topic string, payload []byte) error {
+	b.deliver(Event{
+		Topic:     topic,
+		Origin:    b.instanceID,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package broker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore is an EventStore kept in memory, shared by the brokers under test
+type memoryStore struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *memoryStore) AppendEvent(topic string, origin string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, Event{ID: int64(len(s.events) + 1), Topic: topic, Origin: origin, Payload: payload, CreatedAt: time.Now()})
+	return nil
+}
+
+func (s *memoryStore) ReadEvents(afterID int64, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []Event
+	for _, event := range s.events {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *memoryStore) LastEventID() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.events)), nil
+}
+
+func (s *memoryStore) PruneEvents(before time.Time) error { return nil }
+
+// recorder collects the payloads a subscriber received
+type recorder struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (r *recorder) handle(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads = append(r.payloads, string(event.Payload))
+}
+
+func (r *recorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.payloads...)
+}
+
+// waitFor polls until the recorder has n payloads or a second has passed
+func (r *recorder) waitFor(n int) {
+	deadline := time.Now().Add(time.Second)
+	for len(r.received()) < n && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	b := NewMemoryBroker()
+	var games, admins recorder
+	b.Subscribe(TopicGames, games.handle)
+	b.Subscribe(TopicAdmins, admins.handle)
+
+	if err := b.Publish(TopicGames, []byte("g1")); err != nil {
+		t.Fatal(err)
+	}
+	if got := games.received(); len(got) != 1 || got[0] != "g1" {
+		t.Errorf("games subscriber got %v, want [g1] before Publish returns", got)
+	}
+	if got := admins.received(); len(got) != 0 {
+		t.Errorf("admins subscriber got %v from another topic", got)
+	}
+}
+
+func TestDBBroker(t *testing.T) {
+	store := &memoryStore{}
+	store.AppendEvent(TopicGames, "gone", []byte("before"))
+
+	a, err := NewDBBroker(store, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewDBBroker(store, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if a.InstanceID() == b.InstanceID() {
+		t.Fatal("both instances have the same ID")
+	}
+
+	var onA, onB recorder
+	a.Subscribe(TopicGames, onA.handle)
+	b.Subscribe(TopicGames, onB.handle)
+
+	if err := a.Publish(TopicGames, []byte("from a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(TopicGames, []byte("from b")); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, r := range map[string]*recorder{"a": &onA, "b": &onB} {
+		r.waitFor(2)
+		// Give a stray duplicate of the instance's own event time to show up
+		time.Sleep(20 * time.Millisecond)
+		got := r.received()
+		if len(got) != 2 {
+			t.Errorf("%s received %v, want both events once and nothing from before it started", name, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package broker
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EventStore is the shared table the DBBroker uses to pass events between instances
+type EventStore interface {
+	AppendEvent(topic string, origin string, payload []byte) error
+	ReadEvents(afterID int64, limit int) ([]Event, error)
+	LastEventID() (int64, error)
+	PruneEvents(before time.Time) error
+}
+
+const (
+	// eventBatchSize caps how many events a single poll reads
+	eventBatchSize = 500
+	// eventRetention is how long published events stay in the table
+	eventRetention = time.Hour
+)
+
+// DBBroker shares events between server instances through the database.
+// Each instance appends what it publishes and polls for what others published.
+type DBBroker struct {
+	subscribers
+	store      EventStore
+	instanceID string
+	lastID     int64
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewDBBroker starts polling the store every interval. Events published before
+// the broker started are not replayed.
+func NewDBBroker(store EventStore, interval time.Duration) (*DBBroker, error) {
+	lastID, err := store.LastEventID()
+	if err != nil {
+		return nil, fmt.Errorf("error reading event log position: %w", err)
+	}
+
+	b := &DBBroker{
+		store:      store,
+		instanceID: NewInstanceID(),
+		lastID:     lastID,
+		stop:       make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.poll(interval)
+	return b, nil
+}
+
+func (b *DBBroker) InstanceID() string {
+	return b.instanceID
+}
+
+// Publish delivers the event locally right away and records it for the other instances
+func (b *DBBroker) Publish(topic string, payload []byte) error {
+	b.deliver(Event{
+		Topic:     topic,
+		Origin:    b.instanceID,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	})
+	return b.store.AppendEvent(topic, b.instanceID, payload)
+}
+
+func (b *DBBroker) Close() error {
+	close(b.stop)
+	b.wg.Wait()
+	return nil
+}
+
+func (b *DBBroker) poll(interval time.Duration) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastPrune := time.Now()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			events, err := b.store.ReadEvents(b.lastID, eventBatchSize)
+			if err != nil {
+				slog.Error("polling broker events failed", "err", err)
+				continue
+			}
+			for _, event := range events {
+				b.lastID = event.ID
+				// Our own events were already delivered by Publish
+				if event.Origin != b.instanceID {
+					b.deliver(event)
+				}
+			}
+
+			if time.Since(lastPrune) > time.Minute {
+				lastPrune = time.Now()
+				if err := b.store.PruneEvents(time.Now().Add(-eventRetention)); err != nil {
+					slog.Error("pruning broker events failed", "err", err)
+				}
+			}
+		}
+	}
+}
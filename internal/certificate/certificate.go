@@ -0,0 +1,179 @@
+// Package certificate renders award certificates for a game's players as SVG
+// and PDF.
+package certificate
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"richetechguy/internal/game"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+//go:embed default.svg.tmpl
+var defaultTemplate string
+
+// PodiumRanks is how many ranks get a podium certificate; ties share a rank,
+// so more than three players can be on the podium
+const PodiumRanks = 3
+
+// Certificate is what a template is rendered with
+type Certificate struct {
+	PlayerID  string
+	Name      string
+	Rank      int
+	Tied      bool
+	Place     string // "1st", or "joint 2nd" when tied
+	Award     string
+	Score     int
+	Correct   int
+	Questions int
+	GameName  string
+	Date      string
+}
+
+// New builds the certificate for one player of a game
+func New(results game.Results, player game.PlayerResult) Certificate {
+	place := game.Ordinal(player.Rank)
+	if player.Tied {
+		place = "joint " + place
+	}
+	date := results.EndTime
+	if date.IsZero() {
+		date = time.Now()
+	}
+	return Certificate{
+		PlayerID:  player.PlayerID,
+		Name:      player.Name,
+		Rank:      player.Rank,
+		Tied:      player.Tied,
+		Place:     place,
+		Award:     award(player.Rank),
+		Score:     player.Score,
+		Correct:   player.Correct,
+		Questions: len(results.Questions),
+		GameName:  results.Name,
+		Date:      date.Format("January 2, 2006"),
+	}
+}
+
+// award names the prize for a rank; the game's own name is printed alongside
+func award(rank int) string {
+	switch rank {
+	case 1:
+		return "Champion"
+	case 2:
+		return "Runner-Up"
+	case 3:
+		return "Third Place"
+	default:
+		return "Certificate of Participation"
+	}
+}
+
+// Podium returns the players placed within PodiumRanks
+func Podium(results game.Results) []game.PlayerResult {
+	var podium []game.PlayerResult
+	for _, player := range results.Players {
+		if player.Rank <= PodiumRanks {
+			podium = append(podium, player)
+		}
+	}
+	return podium
+}
+
+// Renderer fills the SVG certificate template
+type Renderer struct {
+	svg *template.Template
+}
+
+// NewRenderer parses the SVG template at path, or uses the built-in design
+// when path is empty. Templates get a Certificate as their data, with its text
+// already escaped for XML.
+func NewRenderer(path string) (*Renderer, error) {
+	text := defaultTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading certificate template: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("certificate").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate template: %w", err)
+	}
+	return &Renderer{svg: tmpl}, nil
+}
+
+// SVG writes c using the renderer's template
+func (r *Renderer) SVG(w io.Writer, c Certificate) error {
+	return r.svg.Execute(w, c.escaped())
+}
+
+// PDF writes c as a one-page A4 PDF drawn from the renderer's SVG template
+func (r *Renderer) PDF(w io.Writer, c Certificate) error {
+	var svg bytes.Buffer
+	if err := r.SVG(&svg, c); err != nil {
+		return err
+	}
+	return writePDF(w, svg.Bytes())
+}
+
+// escaped returns c with its text safe to put in XML. text/template is used
+// rather than html/template, which would escape the <?xml prolog itself.
+func (c Certificate) escaped() Certificate {
+	for _, s := range []*string{&c.PlayerID, &c.Name, &c.Place, &c.Award, &c.GameName, &c.Date} {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(*s))
+		*s = buf.String()
+	}
+	return c
+}
+
+// Zip writes an archive with the SVG and PDF certificate of every podium finisher
+func (r *Renderer) Zip(w io.Writer, results game.Results) error {
+	zw := zip.NewWriter(w)
+	used := make(map[string]bool)
+	for _, player := range Podium(results) {
+		c := New(results, player)
+		// Names that only differ in punctuation share a filename
+		base := Filename(c)
+		for n := 2; used[base]; n++ {
+			base = Filename(c) + "-" + strconv.Itoa(n)
+		}
+		used[base] = true
+		for _, file := range []struct {
+			ext    string
+			render func(io.Writer, Certificate) error
+		}{{".svg", r.SVG}, {".pdf", r.PDF}} {
+			var buf bytes.Buffer
+			if err := file.render(&buf, c); err != nil {
+				return err
+			}
+			f, err := zw.Create(base + file.ext)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+var unsafeFilename = regexp.MustCompile(`[^\p{L}\p{N}_-]+`)
+
+// Filename is a download name without extension, like 1st-Ann. Letters of
+// any script are kept so that names like Zoë and Zoé stay apart.
+func Filename(c Certificate) string {
+	return game.Ordinal(c.Rank) + "-" + unsafeFilename.ReplaceAllString(c.Name, "_")
+}
@@ -0,0 +1,258 @@
+package certificate
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 landscape in points
+const (
+	pageWidth  = 842.0
+	pageHeight = 595.0
+)
+
+// Glyph widths of the standard Helvetica fonts for ASCII 32-126, in 1/1000 em.
+// Standard fonts need no embedding, so these are all we need to centre text.
+var (
+	helvetica = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBold = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+type pdfFont struct {
+	resource string
+	widths   *[95]int
+}
+
+var (
+	regular = pdfFont{"F1", &helvetica}
+	bold    = pdfFont{"F2", &helveticaBold}
+)
+
+// pdfText is the text as PDF's WinAnsi encoding can show it: Latin-1
+// characters are kept and anything else becomes '?'
+func pdfText(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			r = '?'
+		}
+		out = append(out, byte(r))
+	}
+	return out
+}
+
+func (f pdfFont) width(text []byte, size float64) float64 {
+	total := 0
+	for _, c := range text {
+		if c >= 32 && c <= 126 {
+			total += f.widths[c-32]
+		} else {
+			total += 556 // close enough for accented letters
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+type page struct {
+	bytes.Buffer
+}
+
+func (p *page) color(op string, c string) bool {
+	r, g, b, ok := parseColor(c)
+	if ok {
+		fmt.Fprintf(p, "%.3f %.3f %.3f %s\n", r, g, b, op)
+	}
+	return ok
+}
+
+// text draws a line of text, shrinking it to fit inside the page margins.
+// x and y are in points from the bottom left; anchor is SVG's text-anchor.
+func (p *page) text(f pdfFont, size, spacing, x, y float64, anchor, s string) {
+	text := pdfText(s)
+	width := func() float64 {
+		return f.width(text, size) + spacing*float64(len(text))
+	}
+	for size > 8 && width() > pageWidth-120 {
+		spacing *= (size - 1) / size
+		size--
+	}
+	switch anchor {
+	case "middle":
+		x -= width() / 2
+	case "end":
+		x -= width()
+	}
+	var escaped bytes.Buffer
+	for _, c := range text {
+		if c == '(' || c == ')' || c == '\\' {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(c)
+	}
+	fmt.Fprintf(p, "BT /%s %.1f Tf %.2f Tc %.2f %.2f Td (%s) Tj ET\n", f.resource, size, spacing, x, y, escaped.Bytes())
+}
+
+// style is the presentation SVG elements inherit from their parents
+type style map[string]string
+
+func (s style) with(attrs []xml.Attr) style {
+	inherited := make(style, len(s)+len(attrs))
+	for k, v := range s {
+		inherited[k] = v
+	}
+	for _, attr := range attrs {
+		inherited[attr.Name.Local] = attr.Value
+	}
+	return inherited
+}
+
+func (s style) number(name string) float64 {
+	n, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s[name]), "px"), 64)
+	return n
+}
+
+// parseColor reads #rgb and #rrggbb colours; "none" and anything else is no colour
+func parseColor(c string) (r, g, b float64, ok bool) {
+	c = strings.TrimPrefix(strings.TrimSpace(c), "#")
+	if len(c) == 3 {
+		c = string([]byte{c[0], c[0], c[1], c[1], c[2], c[2]})
+	}
+	if len(c) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(c, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return float64(v>>16) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255, true
+}
+
+// writePDF draws an SVG certificate on an A4 page. It understands what
+// certificate designs are made of, rect, line and text grouped with g, and
+// skips any other element.
+func writePDF(w io.Writer, svg []byte) error {
+	var p page
+	// SVG coordinates are scaled into the page and y flipped, as PDF's origin
+	// is the bottom left
+	scale, offsetX, offsetY := 1.0, 0.0, 0.0
+	x := func(v float64) float64 { return offsetX + v*scale }
+	y := func(v float64) float64 { return pageHeight - offsetY - v*scale }
+
+	decoder := xml.NewDecoder(bytes.NewReader(svg))
+	styles := []style{{"fill": "#000000"}}
+	var text *strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading certificate SVG: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			s := styles[len(styles)-1].with(t.Attr)
+			styles = append(styles, s)
+			switch t.Name.Local {
+			case "svg":
+				if box := strings.Fields(strings.ReplaceAll(s["viewBox"], ",", " ")); len(box) == 4 {
+					width, _ := strconv.ParseFloat(box[2], 64)
+					height, _ := strconv.ParseFloat(box[3], 64)
+					if width > 0 && height > 0 {
+						scale = min(pageWidth/width, pageHeight/height)
+						offsetX = (pageWidth - width*scale) / 2
+						offsetY = (pageHeight - height*scale) / 2
+					}
+				}
+			case "rect":
+				filled := p.color("rg", s["fill"])
+				stroked := p.color("RG", s["stroke"])
+				fmt.Fprintf(&p, "%.2f w %.2f %.2f %.2f %.2f re ", s.number("stroke-width")*scale,
+					x(s.number("x")), y(s.number("y")+s.number("height")), s.number("width")*scale, s.number("height")*scale)
+				switch {
+				case filled && stroked:
+					p.WriteString("B\n")
+				case filled:
+					p.WriteString("f\n")
+				case stroked:
+					p.WriteString("S\n")
+				default:
+					p.WriteString("n\n")
+				}
+			case "line":
+				if p.color("RG", s["stroke"]) {
+					fmt.Fprintf(&p, "%.2f w %.2f %.2f m %.2f %.2f l S\n", s.number("stroke-width")*scale,
+						x(s.number("x1")), y(s.number("y1")), x(s.number("x2")), y(s.number("y2")))
+				}
+			case "text":
+				text = new(strings.Builder)
+			}
+		case xml.CharData:
+			if text != nil {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			s := styles[len(styles)-1]
+			styles = styles[:len(styles)-1]
+			if t.Name.Local != "text" || text == nil {
+				continue
+			}
+			font := regular
+			if weight := s["font-weight"]; weight == "bold" || weight == "bolder" || s.number("font-weight") >= 600 {
+				font = bold
+			}
+			size := s.number("font-size")
+			if size == 0 {
+				size = 16
+			}
+			p.color("rg", s["fill"])
+			p.text(font, size*scale, s.number("letter-spacing")*scale, x(s.number("x")), y(s.number("y")),
+				s["text-anchor"], strings.Join(strings.Fields(text.String()), " "))
+			text = nil
+		}
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 %.0f %.0f] >>", pageWidth, pageHeight),
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.Len(), p.String()),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := out.WriteTo(w)
+	return err
+}
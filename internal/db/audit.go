@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"richetechguy/internal/types"
+	"time"
+)
+
+// RecordAudit appends a host action to a game's audit log
+func (d *DB) RecordAudit(entry types.AuditEntry) error {
+	ctx := context.Background()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := d.db.ExecContext(ctx, `
+        INSERT INTO game_audit (game_id, action, detail, created_at) VALUES (?, ?, ?, ?)
+    `, entry.GameID, entry.Action, entry.Detail, entry.CreatedAt)
+	return track("record_audit", err)
+}
+
+// AuditLog returns a game's newest audit entries first
+func (d *DB) AuditLog(gameID string, limit int) (entries []types.AuditEntry, err error) {
+	ctx := context.Background()
+	defer func() { track("audit_log", err) }()
+
+	rows, err := d.db.QueryContext(ctx, `
+        SELECT id, game_id, action, detail, created_at
+        FROM game_audit
+        WHERE game_id = ?
+        ORDER BY id DESC
+        LIMIT ?
+    `, gameID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry types.AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.GameID, &entry.Action, &entry.Detail, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
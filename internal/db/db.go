@@ -4,32 +4,96 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
+	"richetechguy/internal/metrics"
 	"richetechguy/internal/types"
+	"sort"
+	"strings"
 	"sync"
-	// "time"
+	"time"
 )
 
 type DB struct {
 	db *sql.DB
 }
 
-func (d *DB) LoadGames() (map[string]*types.GameState, error) {
+// track counts a failed operation in the DB error metric and passes err through
+func track(op string, err error) error {
+	if err != nil {
+		metrics.DBErrors.Inc(op)
+	}
+	return err
+}
+
+func (d *DB) LoadGames() (games map[string]*types.GameState, err error) {
 	ctx := context.Background()
+	defer func() { track("load_games", err) }()
+
+	loaded, err := d.queryGames(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	games = make(map[string]*types.GameState, len(loaded))
+	for _, game := range loaded {
+		games[game.ID] = game
+	}
+
+	players, err := d.loadPlayers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, player := range players {
+		if game, ok := games[player.GameID]; ok {
+			game.Players[player.ID] = player
+		}
+	}
+	return games, nil
+}
 
+// LoadGame loads one game and its players, or returns sql.ErrNoRows
+func (d *DB) LoadGame(gameID string) (game *types.GameState, err error) {
+	ctx := context.Background()
+	defer func() {
+		if err != sql.ErrNoRows {
+			track("load_game", err)
+		}
+	}()
+
+	loaded, err := d.queryGames(ctx, "WHERE id = ?", gameID)
+	if err != nil {
+		return nil, err
+	}
+	if len(loaded) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	game = loaded[0]
+
+	players, err := d.loadPlayers(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	for _, player := range players {
+		game.Players[player.ID] = player
+	}
+	return game, nil
+}
+
+// queryGames reads game rows matching an optional WHERE clause, without players
+func (d *DB) queryGames(ctx context.Context, where string, args ...interface{}) ([]*types.GameState, error) {
 	rows, err := d.db.QueryContext(ctx, `
-        SELECT id, name, is_active, start_time, end_time, questions
+        SELECT id, name, is_active, start_time, end_time, questions, presenter_token, theme, voided, bans, late_join, rounds
         FROM games
-    `)
+    `+where, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	games := make(map[string]*types.GameState)
+	var games []*types.GameState
 	for rows.Next() {
 		var game types.GameState
-		var questionsJSON string
+		var questionsJSON, themeJSON, voidedJSON, bansJSON, roundsJSON string
 		var questions []types.Question
 
 		err := rows.Scan(
@@ -39,10 +103,36 @@ func (d *DB) LoadGames() (map[string]*types.GameState, error) {
 			&game.StartTime,
 			&game.EndTime,
 			&questionsJSON,
+			&game.PresenterToken,
+			&themeJSON,
+			&voidedJSON,
+			&bansJSON,
+			&game.LateJoin,
+			&roundsJSON,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal([]byte(themeJSON), &game.Theme); err != nil {
+			return nil, err
+		}
+		var voided []int
+		if err := json.Unmarshal([]byte(voidedJSON), &voided); err != nil {
+			return nil, err
+		}
+		if len(voided) > 0 {
+			game.Voided = make(map[int]bool, len(voided))
+			for _, id := range voided {
+				game.Voided[id] = true
+			}
+		}
+
+		if err := json.Unmarshal([]byte(bansJSON), &game.Bans); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(roundsJSON), &game.Rounds); err != nil {
+			return nil, err
+		}
 
 		// Parse questions JSON
 		if err := json.Unmarshal([]byte(questionsJSON), &questions); err != nil {
@@ -54,9 +144,8 @@ func (d *DB) LoadGames() (map[string]*types.GameState, error) {
 
 		game.Mu = sync.RWMutex{}
 
-		games[game.ID] = &game
+		games = append(games, &game)
 	}
-
 	return games, rows.Err()
 }
 
@@ -92,10 +181,22 @@ func NewDB(url string, authToken string) (*DB, error) {
 	return &DB{db: db}, nil
 }
 
-// Initialize creates the necessary tables if they don't exist
-func (d *DB) Initialize() error {
-	// Create games table
-	_, err := d.db.Exec(`
+// column is a column added to a table after its first version
+type column struct {
+	name       string
+	definition string
+}
+
+// schema is every table Initialize creates, in order, with the columns added
+// to it since. CheckMigrations checks the database against the same list.
+var schema = []struct {
+	table   string
+	create  string
+	columns []column
+}{
+	{
+		table: "games",
+		create: `
         CREATE TABLE IF NOT EXISTS games (
             id TEXT PRIMARY KEY,
             name TEXT NOT NULL,
@@ -105,34 +206,221 @@ func (d *DB) Initialize() error {
             questions JSON,
             created_at DATETIME DEFAULT CURRENT_TIMESTAMP
         )
-    `)
-	return err
+    `,
+		columns: []column{
+			{"presenter_token", "TEXT NOT NULL DEFAULT ''"},
+			{"theme", "JSON NOT NULL DEFAULT '{}'"},
+			{"voided", "JSON NOT NULL DEFAULT '[]'"},
+			{"bans", "JSON NOT NULL DEFAULT '[]'"},
+			{"late_join", "TEXT NOT NULL DEFAULT 'disallow'"},
+			{"rounds", "JSON NOT NULL DEFAULT '[]'"},
+		},
+	},
+	// Events shared between instances
+	{
+		table: "events",
+		create: `
+        CREATE TABLE IF NOT EXISTS events (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            topic TEXT NOT NULL,
+            origin TEXT NOT NULL,
+            payload TEXT NOT NULL,
+            created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+        )
+    `,
+	},
+	// The saved roster of each game
+	{
+		table: "game_players",
+		create: `
+        CREATE TABLE IF NOT EXISTS game_players (
+            game_id TEXT NOT NULL,
+            player_id TEXT NOT NULL,
+            name TEXT NOT NULL,
+            score INTEGER NOT NULL DEFAULT 0,
+            answers JSON,
+            last_seen DATETIME,
+            PRIMARY KEY (game_id, player_id)
+        )
+    `,
+		columns: []column{
+			{"response_times", "JSON"},
+			{"adjustments", "JSON"},
+			{"rulings", "JSON"},
+			{"anonymous", "BOOLEAN NOT NULL DEFAULT 0"},
+			{"ip", "TEXT NOT NULL DEFAULT ''"},
+		},
+	},
+	// The log of host overrides during games
+	{
+		table: "game_audit",
+		create: `
+        CREATE TABLE IF NOT EXISTS game_audit (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            game_id TEXT NOT NULL,
+            action TEXT NOT NULL,
+            detail TEXT NOT NULL DEFAULT '',
+            created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+        )
+    `,
+	},
+	// The words the dashboard keeps out of player names
+	{
+		table: "name_blocklist",
+		create: `
+        CREATE TABLE IF NOT EXISTS name_blocklist (
+            word TEXT PRIMARY KEY
+        )
+    `,
+	},
+	// Registered webhook endpoints and their delivery log
+	{
+		table: "webhooks",
+		create: `
+        CREATE TABLE IF NOT EXISTS webhooks (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            url TEXT NOT NULL,
+            secret TEXT NOT NULL,
+            events TEXT NOT NULL DEFAULT '',
+            created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+        )
+    `,
+	},
+	{
+		table: "webhook_deliveries",
+		create: `
+        CREATE TABLE IF NOT EXISTS webhook_deliveries (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            webhook_id INTEGER NOT NULL,
+            url TEXT NOT NULL,
+            event_id TEXT NOT NULL,
+            event_type TEXT NOT NULL,
+            attempt INTEGER NOT NULL,
+            status_code INTEGER NOT NULL DEFAULT 0,
+            error TEXT NOT NULL DEFAULT '',
+            duration_ms INTEGER NOT NULL DEFAULT 0,
+            created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+        )
+    `,
+	},
 }
 
-// SaveGame saves or updates a game in the database
-func (d *DB) SaveGame(game *types.GameState) error {
+// Initialize creates the necessary tables if they don't exist, and adds any
+// columns that tables created by an earlier version lack
+func (d *DB) Initialize() error {
+	for _, t := range schema {
+		if _, err := d.db.Exec(t.create); err != nil {
+			return err
+		}
+		for _, c := range t.columns {
+			if err := d.addColumn(t.table, c.name, c.definition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SaveGame saves or updates a game and its roster in the database
+func (d *DB) SaveGame(game *types.GameState) (err error) {
 	ctx := context.Background()
+	defer metrics.SaveGameDuration.ObserveSince(time.Now())
+	defer func() { track("save_game", err) }()
 
-	// Convert questions to JSON
+	game.Mu.RLock()
 	questionsJSON, err := json.Marshal(game.Questions)
 	if err != nil {
+		game.Mu.RUnlock()
+		return err
+	}
+	id, name, isActive, startTime, endTime := game.ID, game.Name, game.IsActive, game.StartTime, game.EndTime
+	presenterToken, lateJoin := game.PresenterToken, game.LateJoin
+	if lateJoin == "" {
+		lateJoin = types.LateJoinDisallow
+	}
+	themeJSON, err := json.Marshal(game.Theme)
+	if err != nil {
+		game.Mu.RUnlock()
+		return err
+	}
+	voided := make([]int, 0, len(game.Voided))
+	for id := range game.Voided {
+		voided = append(voided, id)
+	}
+	sort.Ints(voided)
+	voidedJSON, err := json.Marshal(voided)
+	if err != nil {
+		game.Mu.RUnlock()
+		return err
+	}
+	bans := game.Bans
+	if bans == nil {
+		bans = []types.Ban{}
+	}
+	bansJSON, err := json.Marshal(bans)
+	if err != nil {
+		game.Mu.RUnlock()
+		return err
+	}
+	rounds := game.Rounds
+	if rounds == nil {
+		rounds = []types.Round{}
+	}
+	roundsJSON, err := json.Marshal(rounds)
+	if err != nil {
+		game.Mu.RUnlock()
 		return err
 	}
+	players := make([]*types.Player, 0, len(game.Players))
+	for _, player := range game.Players {
+		players = append(players, player)
+	}
+	game.Mu.RUnlock()
 
-	// Use upsert (INSERT OR REPLACE)
-	_, err = d.db.ExecContext(ctx, `
-        INSERT OR REPLACE INTO games (
-            id, name, is_active, start_time, end_time, questions
-        ) VALUES (?, ?, ?, ?, ?, ?)
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Upsert rather than REPLACE so created_at keeps the original creation time
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO games (
+            id, name, is_active, start_time, end_time, questions, presenter_token, theme, voided, bans, late_join, rounds
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT(id) DO UPDATE SET
+            name = excluded.name,
+            is_active = excluded.is_active,
+            start_time = excluded.start_time,
+            end_time = excluded.end_time,
+            questions = excluded.questions,
+            presenter_token = excluded.presenter_token,
+            theme = excluded.theme,
+            voided = excluded.voided,
+            bans = excluded.bans,
+            late_join = excluded.late_join,
+            rounds = excluded.rounds
     `,
-		game.ID,
-		game.Name,
-		game.IsActive,
-		game.StartTime,
-		game.EndTime,
-		string(questionsJSON))
+		id,
+		name,
+		isActive,
+		startTime,
+		endTime,
+		string(questionsJSON),
+		presenterToken,
+		string(themeJSON),
+		string(voidedJSON),
+		string(bansJSON),
+		string(lateJoin),
+		string(roundsJSON))
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := savePlayers(ctx, tx, id, players); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // LoadGames retrieves all games from the database
@@ -184,13 +472,106 @@ func (d *DB) SaveGame(game *types.GameState) error {
 // DeleteGame removes a game from the database
 func (d *DB) DeleteGame(gameID string) error {
 	ctx := context.Background()
+	if _, err := d.db.ExecContext(ctx, "DELETE FROM game_players WHERE game_id = ?", gameID); err != nil {
+		return track("delete_game", err)
+	}
+	if _, err := d.db.ExecContext(ctx, "DELETE FROM game_audit WHERE game_id = ?", gameID); err != nil {
+		return track("delete_game", err)
+	}
 	_, err := d.db.ExecContext(ctx, "DELETE FROM games WHERE id = ?", gameID)
-	return err
+	return track("delete_game", err)
 }
 
 // ClearAllGames removes all games from the database
 func (d *DB) ClearAllGames() error {
 	ctx := context.Background()
+	if _, err := d.db.ExecContext(ctx, "DELETE FROM game_players"); err != nil {
+		return track("clear_games", err)
+	}
+	if _, err := d.db.ExecContext(ctx, "DELETE FROM game_audit"); err != nil {
+		return track("clear_games", err)
+	}
 	_, err := d.db.ExecContext(ctx, "DELETE FROM games")
+	return track("clear_games", err)
+}
+
+// DeleteGamesBefore removes games created before cutoff that are not running
+// and returns how many were deleted
+func (d *DB) DeleteGamesBefore(cutoff time.Time) (int64, error) {
+	ctx := context.Background()
+	// created_at is CURRENT_TIMESTAMP text in UTC, so the cutoff has to be
+	// written the same way to compare correctly
+	before := cutoff.UTC().Format("2006-01-02 15:04:05")
+	_, err := d.db.ExecContext(ctx, `
+        DELETE FROM game_players WHERE game_id IN (
+            SELECT id FROM games WHERE created_at < ? AND is_active = false
+        )
+    `, before)
+	if err != nil {
+		return 0, track("delete_old_games", err)
+	}
+	_, err = d.db.ExecContext(ctx, `
+        DELETE FROM game_audit WHERE game_id IN (
+            SELECT id FROM games WHERE created_at < ? AND is_active = false
+        )
+    `, before)
+	if err != nil {
+		return 0, track("delete_old_games", err)
+	}
+	res, err := d.db.ExecContext(ctx, "DELETE FROM games WHERE created_at < ? AND is_active = false", before)
+	if err != nil {
+		return 0, track("delete_old_games", err)
+	}
+	n, err := res.RowsAffected()
+	return n, track("delete_old_games", err)
+}
+
+// Ping checks the database can still be reached
+func (d *DB) Ping(ctx context.Context) error {
+	return track("ping", d.db.PingContext(ctx))
+}
+
+// addColumn adds a column to a table created by an earlier version, if it
+// isn't there yet
+func (d *DB) addColumn(table, column, definition string) error {
+	var count int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
+
+// CheckMigrations reports an error naming any table or column of the schema
+// that is missing; the server is not ready without them
+func (d *DB) CheckMigrations(ctx context.Context) error {
+	var missing []string
+	for _, t := range schema {
+		var name string
+		err := d.db.QueryRowContext(ctx,
+			"SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", t.table).Scan(&name)
+		if err == sql.ErrNoRows {
+			missing = append(missing, t.table)
+			continue
+		}
+		if err != nil {
+			return track("check_migrations", err)
+		}
+		for _, c := range t.columns {
+			var count int
+			err := d.db.QueryRowContext(ctx,
+				"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", t.table, c.name).Scan(&count)
+			if err != nil {
+				return track("check_migrations", err)
+			}
+			if count == 0 {
+				missing = append(missing, t.table+"."+c.name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing from the schema: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
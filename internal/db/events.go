@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"richetechguy/internal/broker"
+	"time"
+)
+
+// AppendEvent records an event for the other server instances to pick up
+func (d *DB) AppendEvent(topic string, origin string, payload []byte) error {
+	ctx := context.Background()
+	_, err := d.db.ExecContext(ctx, `
+        INSERT INTO events (topic, origin, payload, created_at) VALUES (?, ?, ?, ?)
+    `, topic, origin, string(payload), time.Now())
+	return track("append_event", err)
+}
+
+// ReadEvents returns up to limit events published after afterID, oldest first
+func (d *DB) ReadEvents(afterID int64, limit int) (events []broker.Event, err error) {
+	ctx := context.Background()
+	defer func() { track("read_events", err) }()
+
+	rows, err := d.db.QueryContext(ctx, `
+        SELECT id, topic, origin, payload, created_at
+        FROM events
+        WHERE id > ?
+        ORDER BY id
+        LIMIT ?
+    `, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event broker.Event
+		var payload string
+		if err := rows.Scan(&event.ID, &event.Topic, &event.Origin, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// LastEventID returns the newest event ID, or 0 if there are none
+func (d *DB) LastEventID() (int64, error) {
+	ctx := context.Background()
+	var id int64
+	err := d.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM events").Scan(&id)
+	return id, track("last_event_id", err)
+}
+
+// PruneEvents deletes events older than before
+func (d *DB) PruneEvents(before time.Time) error {
+	ctx := context.Background()
+	_, err := d.db.ExecContext(ctx, "DELETE FROM events WHERE created_at < ?", before)
+	return track("prune_events", err)
+}
@@ -0,0 +1,46 @@
+package db
+
+import "context"
+
+// NameBlocklist returns the blocked name words added on the dashboard
+func (d *DB) NameBlocklist() (words []string, err error) {
+	ctx := context.Background()
+	defer func() { track("name_blocklist", err) }()
+
+	rows, err := d.db.QueryContext(ctx, "SELECT word FROM name_blocklist ORDER BY word")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// SaveNameBlocklist replaces the blocked name words added on the dashboard
+func (d *DB) SaveNameBlocklist(words []string) (err error) {
+	ctx := context.Background()
+	defer func() { track("save_name_blocklist", err) }()
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM name_blocklist"); err != nil {
+		return err
+	}
+	for _, word := range words {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO name_blocklist (word) VALUES (?)", word); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
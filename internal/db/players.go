@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"richetechguy/internal/types"
+	"time"
+)
+
+// savePlayers replaces a game's saved roster, so kicked players drop out of it
+func savePlayers(ctx context.Context, tx *sql.Tx, gameID string, players []*types.Player) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM game_players WHERE game_id = ?", gameID); err != nil {
+		return err
+	}
+	for _, player := range players {
+		_, lastSeen := player.Presence()
+		answersJSON, err := json.Marshal(player.GetAllAnswers())
+		if err != nil {
+			return err
+		}
+		timesJSON, err := json.Marshal(player.ResponseTimes)
+		if err != nil {
+			return err
+		}
+		adjustmentsJSON, err := json.Marshal(player.Adjustments)
+		if err != nil {
+			return err
+		}
+		rulingsJSON, err := json.Marshal(player.Rulings)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO game_players (
+                game_id, player_id, name, score, answers, response_times, adjustments, rulings, anonymous, ip, last_seen
+            ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        `, gameID, player.ID, player.Name, player.Score, string(answersJSON), string(timesJSON),
+			string(adjustmentsJSON), string(rulingsJSON), player.Anonymous, player.IP, lastSeen)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPlayers returns the saved roster of a game
+func (d *DB) ListPlayers(gameID string) (players []*types.Player, err error) {
+	defer func() { track("list_players", err) }()
+	return d.loadPlayers(context.Background(), gameID)
+}
+
+// loadPlayers reads saved players, for one game or for every game when gameID is empty
+func (d *DB) loadPlayers(ctx context.Context, gameID string) ([]*types.Player, error) {
+	query := `
+        SELECT game_id, player_id, name, score, answers, response_times, adjustments, rulings, anonymous, ip, last_seen
+        FROM game_players
+    `
+	var args []interface{}
+	if gameID != "" {
+		query += " WHERE game_id = ?"
+		args = append(args, gameID)
+	}
+	rows, err := d.db.QueryContext(ctx, query+" ORDER BY game_id, player_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var players []*types.Player
+	for rows.Next() {
+		player := &types.Player{Status: types.PresenceDisconnected}
+		var answersJSON, timesJSON, adjustmentsJSON, rulingsJSON sql.NullString
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&player.GameID, &player.ID, &player.Name, &player.Score, &answersJSON, &timesJSON, &adjustmentsJSON, &rulingsJSON, &player.Anonymous, &player.IP, &lastSeen); err != nil {
+			return nil, err
+		}
+		player.Answers = make(map[int]string)
+		if answersJSON.Valid && answersJSON.String != "" {
+			if err := json.Unmarshal([]byte(answersJSON.String), &player.Answers); err != nil {
+				return nil, err
+			}
+		}
+		// null for players saved before response times were recorded
+		if timesJSON.Valid && timesJSON.String != "" {
+			if err := json.Unmarshal([]byte(timesJSON.String), &player.ResponseTimes); err != nil {
+				return nil, err
+			}
+		}
+		// null for players saved before the host could adjust scores
+		for _, column := range []struct {
+			json  sql.NullString
+			value interface{}
+		}{
+			{adjustmentsJSON, &player.Adjustments},
+			{rulingsJSON, &player.Rulings},
+		} {
+			if column.json.Valid && column.json.String != "" {
+				if err := json.Unmarshal([]byte(column.json.String), column.value); err != nil {
+					return nil, err
+				}
+			}
+		}
+		player.LastSeen = time.Now()
+		if lastSeen.Valid {
+			player.LastSeen = lastSeen.Time
+		}
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"richetechguy/internal/webhook"
+	"strings"
+	"time"
+)
+
+// ListWebhooks returns every registered endpoint, oldest first
+func (d *DB) ListWebhooks() (endpoints []webhook.Endpoint, err error) {
+	ctx := context.Background()
+	defer func() { track("list_webhooks", err) }()
+
+	rows, err := d.db.QueryContext(ctx, `
+        SELECT id, url, secret, events, created_at
+        FROM webhooks
+        ORDER BY id
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var endpoint webhook.Endpoint
+		var events string
+		if err := rows.Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret, &events, &endpoint.CreatedAt); err != nil {
+			return nil, err
+		}
+		if events != "" {
+			endpoint.Events = strings.Split(events, ",")
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+// AddWebhook registers an endpoint and returns its ID
+func (d *DB) AddWebhook(endpoint webhook.Endpoint) (int64, error) {
+	ctx := context.Background()
+	res, err := d.db.ExecContext(ctx, `
+        INSERT INTO webhooks (url, secret, events, created_at) VALUES (?, ?, ?, ?)
+    `, endpoint.URL, endpoint.Secret, strings.Join(endpoint.Events, ","), time.Now())
+	if err != nil {
+		return 0, track("add_webhook", err)
+	}
+	id, err := res.LastInsertId()
+	return id, track("add_webhook", err)
+}
+
+// DeleteWebhook removes an endpoint; its delivery log is kept
+func (d *DB) DeleteWebhook(id int64) error {
+	ctx := context.Background()
+	_, err := d.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	return track("delete_webhook", err)
+}
+
+// RecordDelivery appends an attempt to the delivery log
+func (d *DB) RecordDelivery(delivery webhook.Delivery) error {
+	ctx := context.Background()
+	_, err := d.db.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (
+            webhook_id, url, event_id, event_type, attempt, status_code, error, duration_ms, created_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+		delivery.EndpointID,
+		delivery.URL,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Duration.Milliseconds(),
+		delivery.CreatedAt)
+	return track("record_delivery", err)
+}
+
+// RecentDeliveries returns the newest delivery attempts first
+func (d *DB) RecentDeliveries(limit int) (deliveries []webhook.Delivery, err error) {
+	ctx := context.Background()
+	defer func() { track("recent_deliveries", err) }()
+
+	rows, err := d.db.QueryContext(ctx, `
+        SELECT id, webhook_id, url, event_id, event_type, attempt, status_code, error, duration_ms, created_at
+        FROM webhook_deliveries
+        ORDER BY id DESC
+        LIMIT ?
+    `, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery webhook.Delivery
+		var durationMS int64
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.EndpointID,
+			&delivery.URL,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Attempt,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&durationMS,
+			&delivery.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		delivery.Duration = time.Duration(durationMS) * time.Millisecond
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
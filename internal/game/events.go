@@ -0,0 +1,87 @@
+package game
+
+import (
+	"richetechguy/internal/types"
+	"richetechguy/internal/webhook"
+	"sort"
+)
+
+// Standing is a player's final place in a game. Tied scores share a rank.
+type Standing struct {
+	Rank     int    `json:"rank"`
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+}
+
+// Standings ranks a game's players by score, highest first
+func Standings(game *types.GameState) []Standing {
+	game.Mu.RLock()
+	standings := make([]Standing, 0, len(game.Players))
+	for _, player := range game.Players {
+		standings = append(standings, Standing{PlayerID: player.ID, Name: player.Name, Score: player.Score})
+	}
+	game.Mu.RUnlock()
+	rankStandings(standings)
+	return standings
+}
+
+// rankStandings orders standings by score and numbers their ranks, with
+// equal scores sharing one
+func rankStandings(standings []Standing) {
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		if standings[i].Name != standings[j].Name {
+			return standings[i].Name < standings[j].Name
+		}
+		// Players can share a name; the ID keeps their order stable
+		return standings[i].PlayerID < standings[j].PlayerID
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Score == standings[i-1].Score {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+}
+
+// GameEventData is the webhook payload for game lifecycle events
+type GameEventData struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Players   int        `json:"players"`
+	Standings []Standing `json:"standings,omitempty"` // set on game.ended
+}
+
+// PlayerEventData is the webhook payload for player.joined
+type PlayerEventData struct {
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+}
+
+// QuestionEventData is the webhook payload for question.opened. The correct
+// answer is left out so receivers can't leak it mid-game.
+type QuestionEventData struct {
+	Number     int      `json:"number"`
+	QuestionID int      `json:"questionId"`
+	Text       string   `json:"text"`
+	Options    []string `json:"options"`
+}
+
+// Emit sends an event to the registered webhooks, if any are configured
+func (gm *GameManager) Emit(eventType string, gameID string, data interface{}) {
+	gm.Webhooks.Emit(eventType, gameID, data)
+}
+
+func (gm *GameManager) emitGame(eventType string, game *types.GameState) {
+	game.Mu.RLock()
+	data := GameEventData{ID: game.ID, Name: game.Name, Players: len(game.Players)}
+	game.Mu.RUnlock()
+	if eventType == webhook.EventGameEnded {
+		data.Standings = Standings(game)
+	}
+	gm.Emit(eventType, game.ID, data)
+}
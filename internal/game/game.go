@@ -1,18 +1,33 @@
 package game
 
 import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	// "github.com/gorilla/websocket"
+	"richetechguy/internal/broker"
 	"richetechguy/internal/db"
 	"richetechguy/internal/types"
+	"richetechguy/internal/webhook"
 	"sync"
 	"time"
 )
 
 type GameManager struct {
-	Games map[string]*types.GameState // Change from 'games' to 'Games'
-	mu    sync.RWMutex
-	Db    *db.DB
+	Games  map[string]*types.GameState // Change from 'games' to 'Games'
+	mu     sync.RWMutex
+	Db     *db.DB
+	Broker broker.Broker
+	// MaxPlayersPerGame caps each game's roster; zero means no cap
+	MaxPlayersPerGame int
+	// Webhooks receives game events; nil disables them
+	Webhooks *webhook.Dispatcher
+	// NameRules limits player names; the zero value uses DefaultNameRules
+	NameRules NameRules
+	// Blocklist filters player names; nil allows any name
+	Blocklist *Blocklist
 }
 
 // StartGame starts a specific game
@@ -21,43 +36,187 @@ func (gm *GameManager) StartGame(gameID string, qm *QuestionManager) error {
 	if err != nil {
 		return err
 	}
-	if qm.GetQuestions() == nil {
+
+	// Games created with their own pack keep it; the rest get their own copy
+	// of the shared bank
+	game.Mu.Lock()
+	if len(game.Questions) == 0 {
+		game.Questions = qm.GetQuestions()
+	}
+	game.Mu.Unlock()
+	if err := game.StartGame(); err != nil {
 		return err
 	}
+	gm.Sync(game)
+	gm.emitGame(webhook.EventGameStarted, game)
+	return nil
+}
 
-	game.Questions = qm.GetQuestions()
-	return game.StartGame()
+// StartSelfPaced opens a running game's whole deck, so each player works
+// through it at their own pace instead of the host's
+func (gm *GameManager) StartSelfPaced(gameID string) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	game.Mu.Lock()
+	if !game.IsActive {
+		game.Mu.Unlock()
+		return nil, fmt.Errorf("game %s is not active", gameID)
+	}
+	game.SelfPaced = true
+	game.Mu.Unlock()
+
+	gm.Sync(game)
+	return game, nil
 }
+
+// SetQuestions gives a game that hasn't started its own question pack, split
+// into rounds when the pack has them
+func (gm *GameManager) SetQuestions(gameID string, questions []types.Question, rounds []types.Round) error {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return err
+	}
+	game.Mu.Lock()
+	if game.IsActive {
+		game.Mu.Unlock()
+		return fmt.Errorf("cannot change the questions of an active game")
+	}
+	game.Questions = questions
+	game.Rounds = rounds
+	game.Mu.Unlock()
+
+	gm.Sync(game)
+	return gm.Db.SaveGame(game)
+}
+
+// NextQuestion opens the next question of a game. The question is nil when
+// the game went into the intermission after a round instead.
+func (gm *GameManager) NextQuestion(gameID string) (*types.GameState, *types.Question, error) {
+	return gm.openQuestion(gameID, (*types.GameState).NextQuestion)
+}
+
+// openQuestion moves a game on with open, which opens its next question or
+// starts an intermission, and tells everyone who needs to know
+func (gm *GameManager) openQuestion(gameID string, open func(*types.GameState) (*types.Question, error)) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ranks := BuildLeaderboard(game).Ranks()
+	question, err := open(game)
+	if err != nil {
+		return nil, nil, err
+	}
+	if question == nil {
+		gm.Sync(game)
+		return game, nil, nil
+	}
+	game.Mu.Lock()
+	game.RanksBefore = ranks
+	game.Mu.Unlock()
+	gm.Sync(game)
+
+	game.Mu.RLock()
+	number := game.Round
+	game.Mu.RUnlock()
+	gm.Emit(webhook.EventQuestionOpened, game.ID, QuestionEventData{
+		Number:     number,
+		QuestionID: question.ID,
+		Text:       question.Text,
+		Options:    question.Options,
+	})
+	return game, question, nil
+}
+
 func (gm *GameManager) SelectGame(gameID string) (*types.GameState, error) {
 	game, err := gm.GetGame(gameID)
 	if err != nil {
 		return nil, err
 	}
 	game.StartGame()
+	gm.Sync(game)
 	return game, nil
 }
 
-// AddPlayer adds a player to a game
-func (gm *GameManager) AddPlayer(gameID string, playerName string) (string, error) {
+// NewPlayerID returns a random player ID. IDs must never be reused within a
+// game, or a newcomer could take over the answers of a player who was kicked.
+func NewPlayerID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "player_" + hex.EncodeToString(b)
+}
+
+// AddPlayer adds a player who joined from ip to a game, under their name as
+// NormalizeName tidies it. Names that break the rules return a *NameError.
+// Once the game has started the game's LateJoinPolicy decides whether they
+// get in and with what score.
+func (gm *GameManager) AddPlayer(gameID string, playerName string, ip string) (string, error) {
+	return gm.addPlayer(gameID, playerName, ip, false)
+}
+
+// AddGuest adds an anonymous player with a generated name, for sockets that
+// connect without one
+func (gm *GameManager) AddGuest(gameID string, ip string) (string, error) {
+	return gm.addPlayer(gameID, "", ip, true)
+}
+
+func (gm *GameManager) addPlayer(gameID, playerName, ip string, anonymous bool) (string, error) {
 	game, err := gm.GetGame(gameID)
 	if err != nil {
 		return "", err
 	}
-	game.Mu.Lock()
-	defer game.Mu.Unlock()
-
-	if game.IsActive {
-		return "", fmt.Errorf("cannot join active game")
+	if game.IsBanned("", ip) {
+		return "", types.ErrBanned
+	}
+	handicap, err := lateJoinHandicap(game)
+	if err != nil {
+		return "", err
+	}
+	if anonymous {
+		playerName = gm.GuestName(game)
+	} else if playerName, err = gm.CheckName(game, playerName, ""); err != nil {
+		return "", err
 	}
 
-	playerID := fmt.Sprintf("player_%d", len(game.Players)+1)
-	game.Players[playerID] = &types.Player{
-		ID:      playerID,
-		Name:    playerName,
-		Score:   0,
-		Answers: make(map[int]string),
+	playerID := NewPlayerID()
+	player := &types.Player{
+		ID:        playerID,
+		Name:      playerName,
+		Score:     0,
+		Answers:   make(map[int]string),
+		GameID:    gameID,
+		Anonymous: anonymous,
+		IP:        ip,
+		// Presence flips to connected once the lobby opens its socket
+		Status:   types.PresenceDisconnected,
+		LastSeen: time.Now(),
+	}
+	if handicap != nil {
+		player.Adjustments = []types.ScoreAdjustment{*handicap}
+		player.Score = handicap.Delta
+	}
+	for {
+		err := game.AddPlayer(player, gm.MaxPlayersPerGame, nameKey)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, types.ErrNameTaken) {
+			return "", err
+		}
+		// Someone joined with the name after it was checked
+		if !anonymous {
+			return "", gm.nameTakenError(game, playerName, "")
+		}
+		player.Name = gm.GuestName(game)
+		playerName = player.Name
 	}
 
+	gm.Sync(game)
+	gm.Emit(webhook.EventPlayerJoined, gameID, PlayerEventData{PlayerID: playerID, Name: playerName})
 	return playerID, nil
 }
 
@@ -73,33 +232,74 @@ func (gm *GameManager) GetAllGames() map[string]*types.GameState {
 	}
 	return games
 }
+
+// EndGame stops a game for good. It returns types.ErrGameEnded when the game
+// had already ended.
 func (gm *GameManager) EndGame(gameID string) error {
-	gm.mu.Lock()
-	defer gm.mu.Unlock()
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil
+	}
 
-	if game, exists := gm.Games[gameID]; exists {
-		game.Mu.Lock()
-		game.IsActive = false
-		game.EndTime = time.Now()
+	game.Mu.Lock()
+	if !game.EndTime.IsZero() {
 		game.Mu.Unlock()
-
-		// Save to database
-		return gm.Db.SaveGame(game)
+		return types.ErrGameEnded
 	}
-	return nil
+	game.IsActive = false
+	game.EndTime = time.Now()
+	game.Mu.Unlock()
+	gm.Sync(game)
+	gm.emitGame(webhook.EventGameEnded, game)
+
+	// Save to database
+	return gm.Db.SaveGame(game)
 }
 
 func (gm *GameManager) ClearAllGames() error {
 	gm.mu.Lock()
-	defer gm.mu.Unlock()
-
 	// Clear from memory
 	gm.Games = make(map[string]*types.GameState)
+	gm.mu.Unlock()
+
+	if gm.Broker != nil {
+		gm.publishGameEvent(gameEvent{Cleared: true})
+	}
 
 	// Clear from database
 	return gm.Db.ClearAllGames()
 }
 
+// DeleteGamesBefore removes games created before cutoff that aren't running
+// and returns how many were deleted
+func (gm *GameManager) DeleteGamesBefore(cutoff time.Time) (int64, error) {
+	deleted, err := gm.Db.DeleteGamesBefore(cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	// The database is the source of truth for creation times, so drop
+	// whatever it no longer has
+	remaining, err := gm.Db.LoadGames()
+	if err != nil {
+		return deleted, err
+	}
+	var removed []string
+	gm.mu.Lock()
+	for id := range gm.Games {
+		if _, ok := remaining[id]; !ok {
+			delete(gm.Games, id)
+			removed = append(removed, id)
+		}
+	}
+	gm.mu.Unlock()
+
+	if gm.Broker != nil && len(removed) > 0 {
+		gm.publishGameEvent(gameEvent{Deleted: removed})
+	}
+	return deleted, nil
+}
+
 // Update NewGameManager to initialize Games instead of games
 
 func NewGameManager(dbURL, authToken string) (*GameManager, error) {
@@ -119,35 +319,50 @@ func NewGameManager(dbURL, authToken string) (*GameManager, error) {
 		return nil, err
 	}
 
-	return &GameManager{
+	// Games saved before presenter views existed get a token now
+	for _, game := range games {
+		if game.PresenterToken == "" {
+			game.PresenterToken = newPresenterToken()
+			if err := database.SaveGame(game); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	gm := &GameManager{
 		Games: games,
 		Db:    database,
-	}, nil
+	}
+	gm.UseBroker(broker.NewMemoryBroker())
+	gm.ExportMetrics()
+	return gm, nil
 }
 func NewGameState(name string) *types.GameState {
 	gameID := fmt.Sprintf("game_%d", time.Now().UnixNano())
 
 	return &types.GameState{
-		ID:       gameID,
-		Name:     name,
-		Players:  make(map[string]*types.Player),
-		IsActive: false,
-		Round:    0,
-		Mu:       sync.RWMutex{},
+		ID:             gameID,
+		Name:           name,
+		Players:        make(map[string]*types.Player),
+		IsActive:       false,
+		Round:          0,
+		PresenterToken: newPresenterToken(),
+		Mu:             sync.RWMutex{},
 	}
 }
 func (gm *GameManager) CreateGame(name string) (*types.GameState, error) {
 	gm.mu.Lock()
-	defer gm.mu.Unlock()
-
 	game := NewGameState(name)
 	gm.Games[game.ID] = game
+	gm.mu.Unlock()
 
 	// Save to database
 	if err := gm.Db.SaveGame(game); err != nil {
 		return nil, err
 	}
 
+	gm.Sync(game)
+	gm.emitGame(webhook.EventGameCreated, game)
 	return game, nil
 }
 
@@ -179,6 +394,22 @@ func (gm *GameManager) GetGame(gameID string) (*types.GameState, error) {
 	return game, nil
 }
 
+// FindGame looks a game up in memory and then in the database, so results
+// stay reachable for games this instance never loaded
+func (gm *GameManager) FindGame(gameID string) (*types.GameState, error) {
+	if game, err := gm.GetGame(gameID); err == nil {
+		return game, nil
+	}
+	if gm.Db == nil {
+		return nil, fmt.Errorf("game not found: %s", gameID)
+	}
+	game, err := gm.Db.LoadGame(gameID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("game not found: %s", gameID)
+	}
+	return game, err
+}
+
 func (gm *GameManager) GetFirstGameID() string {
 	gm.mu.RLock()
 	defer gm.mu.RUnlock()
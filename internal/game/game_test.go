@@ -0,0 +1,38 @@
+package game
+
+import (
+	"richetechguy/internal/types"
+	"testing"
+)
+
+func TestStartGameCopiesDeck(t *testing.T) {
+	qm := &QuestionManager{questions: []types.Question{
+		{ID: 1, Type: types.SingleChoice, Text: "One?", Options: []string{"a", "b"}, Correct: "1"},
+	}}
+	gameA, gameB := NewGameState("A"), NewGameState("B")
+	gm := &GameManager{Games: map[string]*types.GameState{gameA.ID: gameA, gameB.ID: gameB}}
+	for _, game := range []*types.GameState{gameA, gameB} {
+		game.Players["p"] = &types.Player{ID: "p", Name: "Pat", Answers: map[int]string{}}
+		if err := gm.StartGame(game.ID, qm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := gameA.AcceptAnswer(1, "2"); err != nil {
+		t.Fatal(err)
+	}
+	gameA.Questions[0].Options[0] = "changed"
+
+	if got := gameA.Questions[0].Accepted; len(got) != 1 {
+		t.Errorf("game A accepts %v, want [2]", got)
+	}
+	decks := map[string][]types.Question{"game B": gameB.Questions, "the bank": qm.questions}
+	for name, deck := range decks {
+		if len(deck[0].Accepted) != 0 {
+			t.Errorf("%s accepts %v after game A's ruling", name, deck[0].Accepted)
+		}
+		if deck[0].Options[0] != "a" {
+			t.Errorf("%s has options %v after game A's change", name, deck[0].Options)
+		}
+	}
+}
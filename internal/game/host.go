@@ -0,0 +1,278 @@
+package game
+
+import (
+	"fmt"
+	"log/slog"
+	"richetechguy/internal/types"
+	"time"
+)
+
+// HostAction is something the host does to a running game. Everything but
+// next, lock and reveal overrides the normal flow and goes in the audit log.
+type HostAction string
+
+const (
+	ActionNext     HostAction = "next"
+	ActionLock     HostAction = "lock"
+	ActionReveal   HostAction = "reveal"
+	ActionPause    HostAction = "pause"
+	ActionResume   HostAction = "resume"
+	ActionSkip     HostAction = "skip"     // void the current question and open the next
+	ActionReopen   HostAction = "reopen"   // take answers to a locked question again
+	ActionExtend   HostAction = "extend"   // add time to the current question
+	ActionVoid     HostAction = "void"     // take back every point a question gave
+	ActionAdjust   HostAction = "adjust"   // award or deduct points by hand
+	ActionAccept   HostAction = "accept"   // accept another answer to a question
+	ActionRule     HostAction = "rule"     // mark one player's answer right or wrong
+	ActionKick     HostAction = "kick"     // remove a player, who may join again
+	ActionBan      HostAction = "ban"      // remove a player and keep them out
+	ActionRename   HostAction = "rename"   // replace an offensive name
+	ActionLateJoin HostAction = "latejoin" // change who can join once the game started
+)
+
+// MaxExtension caps how many seconds one extension can add
+const MaxExtension = 300
+
+// HostView is what the dashboard's host controls show about a game
+type HostView struct {
+	GameID    string
+	Active    bool
+	Paused    bool
+	SelfPaced bool
+	Question  *types.Question // the current question, if one is open
+	Number    int
+	Total     int
+	Locked    bool
+	Revealed  bool
+	Deadline  time.Time
+	Questions []types.Question
+	Voided    map[int]bool
+	LateJoin  types.LateJoinPolicy
+	Rounds    []RoundProgress
+	// IntermissionUntil is when the break between rounds ends; zero outside one
+	IntermissionUntil time.Time
+}
+
+// BuildHostView snapshots a game for the host controls
+func BuildHostView(game *types.GameState) HostView {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	view := HostView{
+		GameID:            game.ID,
+		Active:            game.IsActive,
+		Paused:            game.IsPaused,
+		SelfPaced:         game.SelfPaced,
+		LateJoin:          game.LateJoin,
+		Rounds:            roundProgress(game),
+		IntermissionUntil: game.IntermissionUntil,
+		Number:            game.Round,
+		Total:             len(game.Questions),
+		Locked:            game.IsLocked,
+		Revealed:          game.IsRevealed,
+		Deadline:          game.Deadline(),
+		Questions:         append([]types.Question(nil), game.Questions...),
+		Voided:            make(map[int]bool, len(game.Voided)),
+	}
+	if game.CurrentQuestion != nil {
+		question := *game.CurrentQuestion
+		view.Question = &question
+	}
+	for id, voided := range game.Voided {
+		view.Voided[id] = voided
+	}
+	return view
+}
+
+// PauseGame freezes a running game and its countdown
+func (gm *GameManager) PauseGame(gameID string) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := game.Pause(); err != nil {
+		return nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionPause, "")
+	return game, nil
+}
+
+// ResumeGame restarts a paused game where it left off
+func (gm *GameManager) ResumeGame(gameID string) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if err := game.Resume(); err != nil {
+		return nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionResume, "")
+	return game, nil
+}
+
+// SkipQuestion voids the current question and opens the next one. It returns
+// the question that was opened, or nil when the skipped question was the last
+// or ended a round with an intermission.
+func (gm *GameManager) SkipQuestion(gameID string) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	game.Mu.Lock()
+	current := game.CurrentQuestion
+	last := game.Round >= len(game.Questions)
+	if current == nil {
+		game.Mu.Unlock()
+		return nil, nil, fmt.Errorf("no active question")
+	}
+	skipped := *current
+	if last {
+		game.IsLocked = true
+	}
+	game.Mu.Unlock()
+
+	// The question may already be void; skipping still moves on
+	game.VoidQuestion(skipped.ID)
+	gm.audit(game, ActionSkip, describeQuestion(skipped))
+
+	var next *types.Question
+	if !last {
+		if _, next, err = gm.NextQuestion(gameID); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		gm.Sync(game)
+	}
+	return game, next, gm.Db.SaveGame(game)
+}
+
+// ExpireTimers moves a game on when its clock runs out: the open question is
+// locked once its countdown is over, and the next round opens when an
+// intermission ends. It returns the action taken, or "" when nothing was due.
+func (gm *GameManager) ExpireTimers(gameID string) (*types.GameState, HostAction, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	now := time.Now()
+	if question := game.LockExpired(now); question != nil {
+		gm.Sync(game)
+		return game, ActionLock, question, nil
+	}
+	game.Mu.RLock()
+	due := !game.IntermissionUntil.IsZero() && !now.Before(game.IntermissionUntil)
+	game.Mu.RUnlock()
+	if !due {
+		return game, "", nil, nil
+	}
+	game, question, err := gm.openQuestion(gameID, func(game *types.GameState) (*types.Question, error) {
+		return game.EndIntermission(now)
+	})
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return game, ActionNext, question, nil
+}
+
+// LockQuestion stops a game's current question taking answers
+func (gm *GameManager) LockQuestion(gameID string) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	question, err := game.LockQuestion()
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	return game, question, nil
+}
+
+// RevealAnswer locks a game's current question and shows its answer
+func (gm *GameManager) RevealAnswer(gameID string) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	question, err := game.RevealAnswer()
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	return game, question, nil
+}
+
+// ReopenQuestion takes answers to the current question again, with a fresh countdown
+func (gm *GameManager) ReopenQuestion(gameID string) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	question, err := game.ReopenQuestion()
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionReopen, describeQuestion(*question))
+	return game, question, nil
+}
+
+// ExtendTimer adds seconds to the current question's countdown
+func (gm *GameManager) ExtendTimer(gameID string, seconds int) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if seconds > MaxExtension {
+		return nil, nil, fmt.Errorf("extensions are limited to %d seconds", MaxExtension)
+	}
+	question, err := game.ExtendTimer(seconds)
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionExtend, fmt.Sprintf("%s by %ds", describeQuestion(*question), seconds))
+	return game, question, nil
+}
+
+// VoidQuestion takes back the points a question gave and stops it scoring
+func (gm *GameManager) VoidQuestion(gameID string, questionID int) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	question, err := game.VoidQuestion(questionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionVoid, describeQuestion(*question))
+	return game, question, gm.Db.SaveGame(game)
+}
+
+// AuditLog returns a game's newest host actions first
+func (gm *GameManager) AuditLog(gameID string, limit int) ([]types.AuditEntry, error) {
+	if gm.Db == nil {
+		return nil, nil
+	}
+	return gm.Db.AuditLog(gameID, limit)
+}
+
+// audit records a host action. The action has already happened, so a failed
+// write is logged rather than returned.
+func (gm *GameManager) audit(game *types.GameState, action HostAction, detail string) {
+	slog.Info("host action", "game_id", game.ID, "action", action, "detail", detail)
+	if gm.Db == nil {
+		return
+	}
+	err := gm.Db.RecordAudit(types.AuditEntry{GameID: game.ID, Action: string(action), Detail: detail})
+	if err != nil {
+		slog.Error("recording host action failed", "game_id", game.ID, "action", action, "err", err)
+	}
+}
+
+func describeQuestion(q types.Question) string {
+	return fmt.Sprintf("question %d (%q)", q.ID, q.Text)
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"fmt"
+	"richetechguy/internal/types"
+	"sort"
+	"time"
+)
+
+// LateJoinPolicies lists the policies in the order the dashboard offers them
+var LateJoinPolicies = []types.LateJoinPolicy{types.LateJoinDisallow, types.LateJoinZero, types.LateJoinMedian}
+
+// ParseLateJoinPolicy checks a policy name, treating empty as disallow
+func ParseLateJoinPolicy(s string) (types.LateJoinPolicy, error) {
+	if s == "" {
+		return types.LateJoinDisallow, nil
+	}
+	for _, policy := range LateJoinPolicies {
+		if string(policy) == s {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("late join policy must be disallow, zero or median")
+}
+
+// DescribeLateJoin explains a policy to the host
+func DescribeLateJoin(policy types.LateJoinPolicy) string {
+	switch policy {
+	case types.LateJoinZero:
+		return "Allow, starting at zero"
+	case types.LateJoinMedian:
+		return "Allow, starting at the median score"
+	default:
+		return "Don't allow"
+	}
+}
+
+// SetLateJoinPolicy changes whether players can join a game once it started
+func (gm *GameManager) SetLateJoinPolicy(gameID string, policy types.LateJoinPolicy) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	game.Mu.Lock()
+	game.LateJoin = policy
+	game.Mu.Unlock()
+
+	gm.Sync(game)
+	gm.audit(game, ActionLateJoin, DescribeLateJoin(policy))
+	return game, gm.Db.SaveGame(game)
+}
+
+// Joinable reports whether new players can still join a game
+func Joinable(game *types.GameState) bool {
+	_, err := lateJoinHandicap(game)
+	return err == nil
+}
+
+// lateJoinHandicap applies a game's late join policy to someone joining now.
+// It returns the points they start with, nil before the game starts or when
+// they start at zero, or an error when they can't join.
+func lateJoinHandicap(game *types.GameState) (*types.ScoreAdjustment, error) {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	switch {
+	case !game.EndTime.IsZero():
+		return nil, types.ErrGameEnded
+	case !game.IsActive:
+		return nil, nil
+	}
+	switch game.LateJoin {
+	case types.LateJoinZero:
+		return nil, nil
+	case types.LateJoinMedian:
+		if median := medianScore(game); median != 0 {
+			return &types.ScoreAdjustment{Delta: median, Reason: "Late join: median score", At: time.Now()}, nil
+		}
+		return nil, nil
+	default:
+		return nil, types.ErrGameStarted
+	}
+}
+
+// medianScore is the middle score of a game's players, or the average of the
+// middle two. Callers hold the game's lock.
+func medianScore(game *types.GameState) int {
+	scores := make([]int, 0, len(game.Players))
+	for _, player := range game.Players {
+		scores = append(scores, player.Score)
+	}
+	if len(scores) == 0 {
+		return 0
+	}
+	sort.Ints(scores)
+	middle := len(scores) / 2
+	if len(scores)%2 == 1 {
+		return scores[middle]
+	}
+	return (scores[middle-1] + scores[middle]) / 2
+}
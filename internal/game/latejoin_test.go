@@ -0,0 +1,90 @@
+package game
+
+import (
+	"errors"
+	"richetechguy/internal/types"
+	"testing"
+	"time"
+)
+
+func TestParseLateJoinPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    types.LateJoinPolicy
+		wantErr bool
+	}{
+		{in: "", want: types.LateJoinDisallow},
+		{in: "disallow", want: types.LateJoinDisallow},
+		{in: "zero", want: types.LateJoinZero},
+		{in: "median", want: types.LateJoinMedian},
+		{in: "Median", wantErr: true},
+		{in: "average", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLateJoinPolicy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLateJoinPolicy(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLateJoin(t *testing.T) {
+	tests := []struct {
+		name      string
+		active    bool
+		ended     bool
+		policy    types.LateJoinPolicy
+		scores    []int // of the players already in the game
+		wantScore int
+		wantErr   error
+	}{
+		{name: "before the start", policy: types.LateJoinDisallow, scores: []int{10}},
+		{name: "disallowed", active: true, policy: types.LateJoinDisallow, scores: []int{10}, wantErr: types.ErrGameStarted},
+		{name: "no policy", active: true, scores: []int{10}, wantErr: types.ErrGameStarted},
+		{name: "zero", active: true, policy: types.LateJoinZero, scores: []int{30}},
+		{name: "median of odd", active: true, policy: types.LateJoinMedian, scores: []int{40, 10, 20}, wantScore: 20},
+		{name: "median of even", active: true, policy: types.LateJoinMedian, scores: []int{10, 25}, wantScore: 17},
+		{name: "median of nobody", active: true, policy: types.LateJoinMedian},
+		{name: "ended", ended: true, policy: types.LateJoinMedian, scores: []int{10}, wantErr: types.ErrGameEnded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewGameState("test")
+			game.IsActive = tt.active
+			game.LateJoin = tt.policy
+			if tt.ended {
+				game.EndTime = time.Now()
+			}
+			for i, score := range tt.scores {
+				id := string(rune('a' + i))
+				game.Players[id] = &types.Player{ID: id, Name: "Player " + id, Score: score, Answers: map[int]string{}}
+			}
+			gm := &GameManager{Games: map[string]*types.GameState{game.ID: game}}
+
+			if got := Joinable(game); got != (tt.wantErr == nil) {
+				t.Errorf("Joinable = %v, want %v", got, tt.wantErr == nil)
+			}
+			playerID, err := gm.AddPlayer(game.ID, "Latecomer", "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			player := game.Players[playerID]
+			if player.Score != tt.wantScore {
+				t.Errorf("started with %d points, want %d", player.Score, tt.wantScore)
+			}
+			// The head start is an adjustment, so rescoring keeps it
+			game.RecomputeScores()
+			if player.Score != tt.wantScore {
+				t.Errorf("after rescoring has %d points, want %d", player.Score, tt.wantScore)
+			}
+		})
+	}
+}
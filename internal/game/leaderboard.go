@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"richetechguy/internal/types"
+)
+
+// Ranking is a player's place on the leaderboard
+type Ranking struct {
+	Rank     int    `json:"rank"`
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Tied     bool   `json:"tied"`
+	// Delta is how many places the player moved since the current question
+	// opened; positive is up
+	Delta int `json:"delta"`
+}
+
+// Place names the rank, like "4th" or "joint 4th"
+func (r Ranking) Place() string {
+	if r.Tied {
+		return "joint " + Ordinal(r.Rank)
+	}
+	return Ordinal(r.Rank)
+}
+
+// Movement describes Delta for a player, like "up 2"
+func (r Ranking) Movement() string {
+	switch {
+	case r.Delta > 0:
+		return fmt.Sprintf("up %d", r.Delta)
+	case r.Delta < 0:
+		return fmt.Sprintf("down %d", -r.Delta)
+	default:
+		return "no change"
+	}
+}
+
+// Leaderboard ranks a game's players. Equal scores share a rank and are
+// ordered by name, then ID, so the order is the same on every render.
+type Leaderboard struct {
+	Rankings []Ranking
+}
+
+// BuildLeaderboard ranks a game's players as they stand now
+func BuildLeaderboard(game *types.GameState) Leaderboard {
+	standings := Standings(game)
+
+	game.Mu.RLock()
+	before := game.RanksBefore
+	game.Mu.RUnlock()
+
+	rankings := make([]Ranking, len(standings))
+	for i, standing := range standings {
+		rankings[i] = Ranking{
+			Rank:     standing.Rank,
+			PlayerID: standing.PlayerID,
+			Name:     standing.Name,
+			Score:    standing.Score,
+			Tied:     tiedAt(standings, i),
+		}
+		if previous, ok := before[standing.PlayerID]; ok {
+			rankings[i].Delta = previous - standing.Rank
+		}
+	}
+	return Leaderboard{Rankings: rankings}
+}
+
+// Top returns the first n rankings, or all of them when there are fewer
+func (l Leaderboard) Top(n int) []Ranking {
+	if n > len(l.Rankings) {
+		n = len(l.Rankings)
+	}
+	return l.Rankings[:n]
+}
+
+// Around returns the player's ranking with up to n neighbours either side,
+// or nothing when the player isn't ranked
+func (l Leaderboard) Around(playerID string, n int) []Ranking {
+	for i, ranking := range l.Rankings {
+		if ranking.PlayerID != playerID {
+			continue
+		}
+		from, to := i-n, i+n+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(l.Rankings) {
+			to = len(l.Rankings)
+		}
+		return l.Rankings[from:to]
+	}
+	return nil
+}
+
+// Find returns the player's ranking
+func (l Leaderboard) Find(playerID string) (Ranking, bool) {
+	for _, ranking := range l.Rankings {
+		if ranking.PlayerID == playerID {
+			return ranking, true
+		}
+	}
+	return Ranking{}, false
+}
+
+// Ranks maps each player to their rank, for working out Delta later
+func (l Leaderboard) Ranks() map[string]int {
+	ranks := make(map[string]int, len(l.Rankings))
+	for _, ranking := range l.Rankings {
+		ranks[ranking.PlayerID] = ranking.Rank
+	}
+	return ranks
+}
+
+// tiedAt reports whether the standing at i shares its rank with a neighbour
+func tiedAt(standings []Standing, i int) bool {
+	return (i > 0 && standings[i-1].Rank == standings[i].Rank) ||
+		(i+1 < len(standings) && standings[i+1].Rank == standings[i].Rank)
+}
+
+// Ordinal writes n as 1st, 2nd, 3rd, 4th and so on
+func Ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"reflect"
+	"richetechguy/internal/types"
+	"testing"
+)
+
+func TestRankStandings(t *testing.T) {
+	tests := []struct {
+		name      string
+		standings []Standing
+		want      []Standing
+	}{
+		{
+			name: "distinct scores",
+			standings: []Standing{
+				{PlayerID: "a", Name: "Ann", Score: 10},
+				{PlayerID: "b", Name: "Bob", Score: 30},
+				{PlayerID: "c", Name: "Cy", Score: 20},
+			},
+			want: []Standing{
+				{Rank: 1, PlayerID: "b", Name: "Bob", Score: 30},
+				{Rank: 2, PlayerID: "c", Name: "Cy", Score: 20},
+				{Rank: 3, PlayerID: "a", Name: "Ann", Score: 10},
+			},
+		},
+		{
+			name: "ties share a rank and skip the next",
+			standings: []Standing{
+				{PlayerID: "d", Name: "Dee", Score: 10},
+				{PlayerID: "c", Name: "Cy", Score: 20},
+				{PlayerID: "b", Name: "Bob", Score: 20},
+				{PlayerID: "a", Name: "Ann", Score: 30},
+			},
+			want: []Standing{
+				{Rank: 1, PlayerID: "a", Name: "Ann", Score: 30},
+				{Rank: 2, PlayerID: "b", Name: "Bob", Score: 20},
+				{Rank: 2, PlayerID: "c", Name: "Cy", Score: 20},
+				{Rank: 4, PlayerID: "d", Name: "Dee", Score: 10},
+			},
+		},
+		{
+			name: "same name ordered by ID",
+			standings: []Standing{
+				{PlayerID: "p2", Name: "Sam", Score: 0},
+				{PlayerID: "p1", Name: "Sam", Score: 0},
+			},
+			want: []Standing{
+				{Rank: 1, PlayerID: "p1", Name: "Sam", Score: 0},
+				{Rank: 1, PlayerID: "p2", Name: "Sam", Score: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rankStandings(tt.standings)
+			if !reflect.DeepEqual(tt.standings, tt.want) {
+				t.Errorf("got %+v, want %+v", tt.standings, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildLeaderboard(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores map[string]int
+		before map[string]int // RanksBefore
+		want   []Ranking
+	}{
+		{
+			name:   "first question",
+			scores: map[string]int{"Ann": 10, "Bob": 0},
+			want: []Ranking{
+				{Rank: 1, PlayerID: "Ann", Name: "Ann", Score: 10},
+				{Rank: 2, PlayerID: "Bob", Name: "Bob", Score: 0},
+			},
+		},
+		{
+			name:   "moves up and down",
+			scores: map[string]int{"Ann": 10, "Bob": 20, "Cy": 5},
+			before: map[string]int{"Ann": 1, "Bob": 2, "Cy": 3},
+			want: []Ranking{
+				{Rank: 1, PlayerID: "Bob", Name: "Bob", Score: 20, Delta: 1},
+				{Rank: 2, PlayerID: "Ann", Name: "Ann", Score: 10, Delta: -1},
+				{Rank: 3, PlayerID: "Cy", Name: "Cy", Score: 5},
+			},
+		},
+		{
+			name:   "ties",
+			scores: map[string]int{"Ann": 10, "Bob": 10, "Cy": 10, "Dee": 20},
+			before: map[string]int{"Ann": 1, "Bob": 1, "Cy": 1, "Dee": 1},
+			want: []Ranking{
+				{Rank: 1, PlayerID: "Dee", Name: "Dee", Score: 20},
+				{Rank: 2, PlayerID: "Ann", Name: "Ann", Score: 10, Tied: true, Delta: -1},
+				{Rank: 2, PlayerID: "Bob", Name: "Bob", Score: 10, Tied: true, Delta: -1},
+				{Rank: 2, PlayerID: "Cy", Name: "Cy", Score: 10, Tied: true, Delta: -1},
+			},
+		},
+		{
+			name:   "joined since the question opened",
+			scores: map[string]int{"Ann": 10, "New": 0},
+			before: map[string]int{"Ann": 1},
+			want: []Ranking{
+				{Rank: 1, PlayerID: "Ann", Name: "Ann", Score: 10},
+				{Rank: 2, PlayerID: "New", Name: "New", Score: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewGameState("test")
+			for name, score := range tt.scores {
+				game.Players[name] = &types.Player{ID: name, Name: name, Score: score}
+			}
+			game.RanksBefore = tt.before
+			got := BuildLeaderboard(game).Rankings
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"richetechguy/internal/metrics"
+	"richetechguy/internal/types"
+)
+
+// ExportMetrics reports this manager's games through the game gauges
+func (gm *GameManager) ExportMetrics() {
+	metrics.ActiveGames.SetSource(func() []metrics.Sample {
+		active := 0
+		for _, game := range gm.GetAllGames() {
+			game.Mu.RLock()
+			if game.IsActive {
+				active++
+			}
+			game.Mu.RUnlock()
+		}
+		return []metrics.Sample{{Value: float64(active)}}
+	})
+
+	metrics.GamePlayers.SetSource(func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, game := range gm.GetAllGames() {
+			game.Mu.RLock()
+			// Finished games keep their roster but nobody is playing any more
+			if !game.EndTime.IsZero() || len(game.Players) == 0 {
+				game.Mu.RUnlock()
+				continue
+			}
+			counts := map[types.PresenceStatus]int{}
+			for _, player := range game.Players {
+				status, _ := player.Presence()
+				counts[status]++
+			}
+			game.Mu.RUnlock()
+
+			for _, status := range []types.PresenceStatus{types.PresenceConnected, types.PresenceAway, types.PresenceDisconnected} {
+				samples = append(samples, metrics.Sample{
+					Labels: []string{game.ID, string(status)},
+					Value:  float64(counts[status]),
+				})
+			}
+		}
+		return samples
+	})
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"richetechguy/internal/types"
+)
+
+// KickPlayer removes a player from a game. They can join again.
+func (gm *GameManager) KickPlayer(gameID, playerID, reason string) (*types.GameState, *types.Player, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	player, err := game.RemovePlayer(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionKick, describeRemoval(player, reason))
+	return game, player, gm.Db.SaveGame(game)
+}
+
+// BanPlayer removes a player from a game and refuses their player ID and
+// address for the rest of the game
+func (gm *GameManager) BanPlayer(gameID, playerID, reason string) (*types.GameState, *types.Player, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	player, err := game.BanPlayer(playerID, reason)
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionBan, describeRemoval(player, reason))
+	return game, player, gm.Db.SaveGame(game)
+}
+
+// RenamePlayer replaces a player's name, such as an offensive one. The new
+// name has to meet the same rules as a player's own.
+func (gm *GameManager) RenamePlayer(gameID, playerID, name string) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if name, err = gm.CheckName(game, name, playerID); err != nil {
+		return nil, err
+	}
+	old, err := game.RenamePlayer(playerID, name, nameKey)
+	if errors.Is(err, types.ErrNameTaken) {
+		// Someone took the name after it was checked
+		err = gm.nameTakenError(game, name, playerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionRename, fmt.Sprintf("%q to %q", old, name))
+	return game, gm.Db.SaveGame(game)
+}
+
+func describeRemoval(player *types.Player, reason string) string {
+	if reason == "" {
+		return fmt.Sprintf("%q", player.Name)
+	}
+	return fmt.Sprintf("%q: %s", player.Name, reason)
+}
@@ -0,0 +1,340 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"richetechguy/internal/types"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameRules are the length limits player names must meet, counted in
+// characters after NormalizeName
+type NameRules struct {
+	MinLength int
+	MaxLength int
+}
+
+// DefaultNameRules fit a name on the projector's leaderboard
+func DefaultNameRules() NameRules {
+	return NameRules{MinLength: 2, MaxLength: 24}
+}
+
+// NameRulesFromEnv starts from DefaultNameRules and applies NAME_MIN_LENGTH
+// and NAME_MAX_LENGTH
+func NameRulesFromEnv() (NameRules, error) {
+	rules := DefaultNameRules()
+	for name, limit := range map[string]*int{"NAME_MIN_LENGTH": &rules.MinLength, "NAME_MAX_LENGTH": &rules.MaxLength} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rules, fmt.Errorf("%s: invalid length %q", name, value)
+			}
+			*limit = n
+		}
+	}
+	if rules.MinLength > rules.MaxLength {
+		return rules, fmt.Errorf("NAME_MIN_LENGTH is longer than NAME_MAX_LENGTH")
+	}
+	return rules, nil
+}
+
+// NameError says why a name was refused. Suggestions are free names to
+// offer instead when the name was taken.
+type NameError struct {
+	Reason      string
+	Taken       bool
+	Suggestions []string
+}
+
+func (e *NameError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return e.Reason
+	}
+	last := len(e.Suggestions) - 1
+	if last == 0 {
+		return fmt.Sprintf("%s, try %s", e.Reason, e.Suggestions[0])
+	}
+	return fmt.Sprintf("%s, try %s or %s", e.Reason, strings.Join(e.Suggestions[:last], ", "), e.Suggestions[last])
+}
+
+// maxCombiningMarks stops names piling accents onto one letter
+const maxCombiningMarks = 2
+
+// fullwidthOffset maps fullwidth ASCII (U+FF01 to U+FF5E) back to ASCII
+const fullwidthOffset = 0xFF01 - '!'
+
+// NormalizeName tidies a name the way it will be shown: invisible and
+// control characters are dropped, fullwidth letters become ASCII, runs of
+// stacked accents are cut short and whitespace is collapsed. Without the
+// x/text tables this is the part of NFKC that matters for names.
+func NormalizeName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	var b strings.Builder
+	marks := 0
+	for _, r := range name {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= fullwidthOffset
+		case unicode.IsSpace(r):
+			r = ' '
+		case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs):
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) {
+			if marks++; marks > maxCombiningMarks {
+				continue
+			}
+		} else {
+			marks = 0
+		}
+		b.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// nameKey is what two names must differ in to both be used in a game, so
+// "Mike", "mike" and "M ike" clash
+func nameKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(NormalizeName(name), " ", ""))
+}
+
+// lookalikes undo the usual tricks for getting a word past a filter
+var lookalikes = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	"@", "a", "$", "s", "!", "i", "|", "l",
+)
+
+// filterWords reduces a name or blocked word to runs of lowercase letters,
+// so digit swaps and punctuation don't hide a blocked word
+func filterWords(s string) []string {
+	s = lookalikes.Replace(strings.ToLower(NormalizeName(s)))
+	return strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+// substringMatchLength is how long a blocked word must be to be caught inside
+// other words. Shorter ones only match whole words, so blocking a three letter
+// word doesn't also block the names that happen to contain it.
+const substringMatchLength = 5
+
+// Blocklist refuses names containing blocked words. Configured words come
+// from NAME_BLOCKLIST_FILE and always apply; custom words are edited on the
+// dashboard and stored in the database.
+type Blocklist struct {
+	mu         sync.RWMutex
+	configured []string
+	custom     []string
+}
+
+// NewBlocklist starts a blocklist from the configured words
+func NewBlocklist(configured []string) *Blocklist {
+	return &Blocklist{configured: cleanWords(configured)}
+}
+
+// ReadBlocklistFile reads one word per line, skipping blanks and # comments.
+// An empty path reads nothing.
+func ReadBlocklistFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// Configured returns the words from the configuration file
+func (b *Blocklist) Configured() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]string(nil), b.configured...)
+}
+
+// Custom returns the words added on the dashboard
+func (b *Blocklist) Custom() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]string(nil), b.custom...)
+}
+
+// SetCustom replaces the words added on the dashboard
+func (b *Blocklist) SetCustom(words []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.custom = cleanWords(words)
+}
+
+// Blocks reports whether a name contains a blocked word, either as one of its
+// words, spelled out with spaces or dots, or inside a longer word
+func (b *Blocklist) Blocks(name string) bool {
+	words := filterWords(name)
+	squashed := strings.Join(words, "")
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, list := range [][]string{b.configured, b.custom} {
+		for _, blocked := range list {
+			blocked = strings.Join(filterWords(blocked), "")
+			if blocked == "" {
+				continue
+			}
+			if squashed == blocked ||
+				(utf8.RuneCountInString(blocked) >= substringMatchLength && strings.Contains(squashed, blocked)) {
+				return true
+			}
+			for _, word := range words {
+				if word == blocked {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// cleanWords lowercases, trims, sorts and deduplicates a word list
+func cleanWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	var clean []string
+	for _, word := range words {
+		word = strings.ToLower(NormalizeName(word))
+		if word != "" && !seen[word] {
+			seen[word] = true
+			clean = append(clean, word)
+		}
+	}
+	sort.Strings(clean)
+	return clean
+}
+
+// LoadBlocklist sets up the name filter from the configured word file and
+// the words saved from the dashboard
+func (gm *GameManager) LoadBlocklist(path string) error {
+	configured, err := ReadBlocklistFile(path)
+	if err != nil {
+		return fmt.Errorf("reading name blocklist: %w", err)
+	}
+	custom, err := gm.Db.NameBlocklist()
+	if err != nil {
+		return fmt.Errorf("loading name blocklist: %w", err)
+	}
+	gm.Blocklist = NewBlocklist(configured)
+	gm.Blocklist.SetCustom(custom)
+	return nil
+}
+
+// UpdateBlocklist replaces the dashboard's blocked words, saves them and
+// shares them with the other instances
+func (gm *GameManager) UpdateBlocklist(words []string) error {
+	if gm.Blocklist == nil {
+		gm.Blocklist = NewBlocklist(nil)
+	}
+	gm.Blocklist.SetCustom(words)
+	custom := gm.Blocklist.Custom()
+	if gm.Broker != nil {
+		gm.publishGameEvent(gameEvent{Blocklist: &custom})
+	}
+	return gm.Db.SaveNameBlocklist(custom)
+}
+
+// nameRules returns the configured rules, or the defaults when none were set
+func (gm *GameManager) nameRules() NameRules {
+	if gm.NameRules.MaxLength == 0 {
+		return DefaultNameRules()
+	}
+	return gm.NameRules
+}
+
+// CheckName normalizes a player's name and checks it against the length
+// rules, the blocklist and the other names in the game. playerID is the
+// player being renamed, who may keep their own name; it is empty for joins.
+func (gm *GameManager) CheckName(game *types.GameState, name, playerID string) (string, error) {
+	rules := gm.nameRules()
+	name = NormalizeName(name)
+	switch length := utf8.RuneCountInString(name); {
+	case length == 0:
+		return "", &NameError{Reason: "enter a name"}
+	case length < rules.MinLength:
+		return "", &NameError{Reason: fmt.Sprintf("names need at least %d characters", rules.MinLength)}
+	case length > rules.MaxLength:
+		return "", &NameError{Reason: fmt.Sprintf("names can be at most %d characters", rules.MaxLength)}
+	}
+	if gm.Blocklist != nil && gm.Blocklist.Blocks(name) {
+		return "", &NameError{Reason: "that name is not allowed, pick another"}
+	}
+
+	taken := takenNames(game, playerID)
+	if taken[nameKey(name)] {
+		return "", &NameError{Reason: fmt.Sprintf("%s is already playing", name), Taken: true, Suggestions: suggestNames(name, taken, rules.MaxLength)}
+	}
+	return name, nil
+}
+
+// nameTakenError explains a name that was taken after CheckName passed it,
+// with suggestions when the clash is still there
+func (gm *GameManager) nameTakenError(game *types.GameState, name, playerID string) error {
+	if _, err := gm.CheckName(game, name, playerID); err != nil {
+		return err
+	}
+	return types.ErrNameTaken
+}
+
+// GuestName makes up a free name for a socket that joined without one
+func (gm *GameManager) GuestName(game *types.GameState) string {
+	taken := takenNames(game, "")
+	for {
+		name := fmt.Sprintf("Guest %04d", rand.Intn(10000))
+		if !taken[nameKey(name)] {
+			return name
+		}
+	}
+}
+
+// takenNames returns the name keys in use in a game, leaving out playerID's
+func takenNames(game *types.GameState, playerID string) map[string]bool {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	taken := make(map[string]bool, len(game.Players))
+	for id, player := range game.Players {
+		if id != playerID {
+			taken[nameKey(player.Name)] = true
+		}
+	}
+	return taken
+}
+
+// suggestionCount is how many alternatives a taken name gets
+const suggestionCount = 3
+
+// suggestNames numbers a taken name until it finds free ones, like "Mike 2",
+// shortening it where the number would make it too long
+func suggestNames(name string, taken map[string]bool, maxLength int) []string {
+	var suggestions []string
+	for n := 2; len(suggestions) < suggestionCount && n < 1000; n++ {
+		suffix := " " + strconv.Itoa(n)
+		base := []rune(name)
+		if room := maxLength - len(suffix); len(base) > room {
+			base = base[:room]
+		}
+		candidate := strings.TrimSpace(string(base)) + suffix
+		if !taken[nameKey(candidate)] {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions
+}
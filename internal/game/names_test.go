@@ -0,0 +1,56 @@
+package game
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Ann", "Ann"},
+		{"collapses whitespace", "  Ann \t  Lee\n", "Ann Lee"},
+		{"fullwidth", "\uff21\uff4e\uff4e", "Ann"},
+		{"invisible characters", "A\u200bn\u00adn", "Ann"},
+		{"control characters", "Ann\x07\x1b", "Ann"},
+		{"stacked accents", "Zo\u0308\u0308\u0308\u0308", "Zo\u0308\u0308"},
+		{"invalid UTF-8", "An\xffn", "Ann"},
+		{"accents kept", "Zoë", "Zoë"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.in); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlocklistBlocks(t *testing.T) {
+	blocklist := NewBlocklist([]string{"troll", "bum"})
+	blocklist.SetCustom([]string{"Grr"})
+
+	tests := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{"clean", "Alice", false},
+		{"whole word", "Big Troll", true},
+		{"lookalike digits", "Tr0ll", true},
+		{"spelled out", "T.R.O.L.L", true},
+		{"fullwidth", "ｔｒｏｌｌ", true},
+		{"inside a longer word", "megatroll99", true},
+		{"short word on its own", "Bum Bum", true},
+		{"short word spaced out", "b u m", true},
+		{"short word inside another", "Bumblebee", false},
+		{"custom word", "grr", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blocklist.Blocks(tt.in); got != tt.want {
+				t.Errorf("Blocks(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
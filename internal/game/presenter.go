@@ -0,0 +1,143 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"richetechguy/internal/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// presenterBoardSize is how many players the big-screen leaderboard lists
+const presenterBoardSize = 10
+
+// newPresenterToken returns a random token for a game's presenter view
+func newPresenterToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// CheckPresenterToken reports whether token opens the game's presenter view
+func CheckPresenterToken(game *types.GameState, token string) bool {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	return game.PresenterToken != "" &&
+		subtle.ConstantTimeCompare([]byte(game.PresenterToken), []byte(token)) == 1
+}
+
+// PresenterView is what the big screen shows at one moment. Unlike
+// PlayerView it is the same for everyone watching.
+type PresenterView struct {
+	Phase     Phase
+	GameID    string
+	GameName  string
+	SelfPaced bool
+	Theme     types.Theme
+
+	Question *types.Question
+	Number   int
+	Total    int
+	Round    string    // the name of Question's round, in games with rounds
+	Deadline time.Time // zero when the question has no countdown
+
+	Intermission *Intermission
+
+	Answered int // players who have answered Question
+	Players  int
+	Roster   []*types.Player // the lobby roster
+
+	Counts  []int  // answers per option, shown on reveal
+	Correct []bool // which options are correct, shown on reveal
+
+	Leaderboard []Ranking
+}
+
+// BuildPresenterView works out what the big screen should show right now
+func BuildPresenterView(game *types.GameState) PresenterView {
+	leaderboard := BuildLeaderboard(game).Top(presenterBoardSize)
+	theme := ResolveTheme(game)
+	intermission := BuildIntermission(game)
+
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	view := PresenterView{
+		GameID:      game.ID,
+		GameName:    game.Name,
+		SelfPaced:   game.SelfPaced,
+		Theme:       theme,
+		Total:       len(game.Questions),
+		Players:     len(game.Players),
+		Leaderboard: leaderboard,
+	}
+
+	switch {
+	case !game.IsActive && game.EndTime.IsZero():
+		view.Phase = PhaseLobby
+		for _, p := range game.Players {
+			view.Roster = append(view.Roster, p)
+		}
+		sort.Slice(view.Roster, func(i, j int) bool { return view.Roster[i].Name < view.Roster[j].Name })
+	case !game.IsActive:
+		view.Phase = PhaseFinished
+	case game.IsPaused:
+		view.Phase = PhasePaused
+	case intermission != nil:
+		view.Phase = PhaseIntermission
+		view.Intermission = intermission
+	case game.SelfPaced, game.CurrentQuestion == nil:
+		// Self-paced players each see a different question, so the screen shows the table
+		view.Phase = PhaseReady
+	default:
+		q := game.CurrentQuestion
+		view.Question = q
+		view.Number = game.Round
+		view.Round = roundName(game, q.ID)
+		view.Counts = make([]int, len(q.Options))
+		for _, player := range game.Players {
+			answer, ok := player.Answers[q.ID]
+			if !ok {
+				continue
+			}
+			view.Answered++
+			for _, n := range optionIndexes(answer, len(q.Options)) {
+				view.Counts[n]++
+			}
+		}
+		switch {
+		case game.IsRevealed:
+			view.Phase = PhaseReveal
+			view.Correct = make([]bool, len(q.Options))
+			for _, answer := range append([]string{q.Correct}, q.Accepted...) {
+				for _, n := range optionIndexes(answer, len(q.Options)) {
+					view.Correct[n] = true
+				}
+			}
+		case game.IsLocked:
+			view.Phase = PhaseLocked
+		default:
+			view.Phase = PhaseQuestion
+			view.Deadline = game.Deadline()
+		}
+	}
+	return view
+}
+
+// optionIndexes turns an answer of 1-based option numbers, like "1,3", into
+// 0-based indexes, skipping anything out of range
+func optionIndexes(answer string, options int) []int {
+	var indexes []int
+	for _, part := range strings.Split(answer, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && n >= 1 && n <= options {
+			indexes = append(indexes, n-1)
+		}
+	}
+	return indexes
+}
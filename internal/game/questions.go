@@ -1,8 +1,10 @@
 package game
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"richetechguy/internal/types"
 	"sync"
@@ -23,9 +25,8 @@ func (qm *QuestionManager) AddQuestion(q types.Question) error {
 	qm.mu.Lock()
 	defer qm.mu.Unlock()
 
-	// Validate question
-	if q.Text == "" || len(q.Options) != 4 || q.Correct == "" {
-		return fmt.Errorf("invalid question format")
+	if err := ValidateQuestion(q); err != nil {
+		return err
 	}
 
 	q.ID = len(qm.questions) + 1
@@ -35,10 +36,99 @@ func (qm *QuestionManager) AddQuestion(q types.Question) error {
 	return qm.saveQuestions()
 }
 
+// ValidateQuestion checks a question has text, at least two options (true or
+// false questions have only two) and a correct answer
+func ValidateQuestion(q types.Question) error {
+	if q.Text == "" || len(q.Options) < 2 || q.Correct == "" {
+		return fmt.Errorf("invalid question format: text, at least two options and a correct answer are required")
+	}
+	return nil
+}
+
+// Import adds questions to the bank, or replaces it when replace is set.
+// Nothing is changed unless every question is valid.
+func (qm *QuestionManager) Import(questions []types.Question, replace bool) error {
+	for i, q := range questions {
+		if err := ValidateQuestion(q); err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+	}
+
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	if replace {
+		qm.questions = make([]types.Question, 0, len(questions))
+	}
+	for _, q := range questions {
+		q.ID = len(qm.questions) + 1
+		qm.questions = append(qm.questions, q)
+	}
+	return qm.saveQuestions()
+}
+
+// Export writes the question bank as indented JSON, in the questions.json format
+func (qm *QuestionManager) Export(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(qm.GetQuestions())
+}
+
+// ReadQuestions decodes and validates a question pack, numbering its questions from 1
+func ReadQuestions(r io.Reader) ([]types.Question, error) {
+	var questions []types.Question
+	if err := json.NewDecoder(r).Decode(&questions); err != nil {
+		return nil, fmt.Errorf("reading questions: %w", err)
+	}
+	for i := range questions {
+		if err := ValidateQuestion(questions[i]); err != nil {
+			return nil, fmt.Errorf("question %d: %w", i+1, err)
+		}
+		questions[i].ID = i + 1
+	}
+	return questions, nil
+}
+
+// ReadPack decodes a question pack: either a list of questions in the
+// questions.json format, or {"rounds": [...]} where each round has its own
+// questions. Rounds come back nil for a plain list.
+func ReadPack(r io.Reader) ([]types.Question, []types.Round, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("reading questions: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		questions, err := ReadQuestions(bytes.NewReader(trimmed))
+		return questions, nil, err
+	}
+	var pack struct {
+		Rounds []RoundPack `json:"rounds"`
+	}
+	if err := json.Unmarshal(raw, &pack); err != nil {
+		return nil, nil, fmt.Errorf("reading rounds: %w", err)
+	}
+	if len(pack.Rounds) == 0 {
+		return nil, nil, fmt.Errorf("reading rounds: the pack has no rounds")
+	}
+	return BuildRounds(pack.Rounds)
+}
+
+// LoadPack reads a question pack from a JSON file
+func LoadPack(path string) ([]types.Question, []types.Round, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadPack(f)
+}
+
+// GetQuestions returns a copy of the bank, so a game can rule on its deck
+// without touching the bank or other games
 func (qm *QuestionManager) GetQuestions() []types.Question {
 	qm.mu.RLock()
 	defer qm.mu.RUnlock()
-	return qm.questions
+	return types.CopyQuestions(qm.questions)
 }
 
 func (qm *QuestionManager) saveQuestions() error {
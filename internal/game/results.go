@@ -0,0 +1,232 @@
+package game
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"richetechguy/internal/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Results are a game's final standings with every player's answers
+type Results struct {
+	GameID    string           `json:"gameId"`
+	Name      string           `json:"name"`
+	StartTime time.Time        `json:"startTime"`
+	EndTime   time.Time        `json:"endTime"`
+	Ended     bool             `json:"ended"`
+	Questions []QuestionResult `json:"questions"`
+	Rounds    []types.Round    `json:"rounds,omitempty"`
+	Players   []PlayerResult   `json:"players"`
+}
+
+// QuestionResult is a question with how the room did on it
+type QuestionResult struct {
+	types.Question
+	Answered int `json:"answered"`
+	Right    int `json:"right"`
+	// AvgResponseMs averages the timed answers; zero when none were timed
+	AvgResponseMs int64 `json:"avgResponseMs"`
+	// Voided questions score nothing, so they don't count towards Correct
+	Voided bool `json:"voided,omitempty"`
+}
+
+// PlayerResult is a player's standing and how they answered each question
+type PlayerResult struct {
+	Standing
+	// Tied is set when another player shares this rank
+	Tied          bool           `json:"tied"`
+	Correct       int            `json:"correct"`
+	AvgResponseMs int64          `json:"avgResponseMs"`
+	Answers       []AnswerResult `json:"answers"`
+	// RoundScores are the points from each round, in the order of Results.Rounds
+	RoundScores []int `json:"roundScores,omitempty"`
+	// Adjustments are the points the host awarded or deducted by hand
+	Adjustments []types.ScoreAdjustment `json:"adjustments,omitempty"`
+}
+
+// AnswerResult is one answer, whether it was right and how long it took
+type AnswerResult struct {
+	QuestionID int    `json:"questionId"`
+	Answer     string `json:"answer"`
+	Correct    bool   `json:"correct"`
+	// ResponseMs is zero when the answer wasn't timed
+	ResponseMs int64 `json:"responseMs"`
+	// Ruled is set when the host marked the answer right or wrong by hand
+	Ruled bool `json:"ruled,omitempty"`
+}
+
+// Answer returns the player's answer to a question, if they gave one
+func (p PlayerResult) Answer(questionID int) (AnswerResult, bool) {
+	for _, answer := range p.Answers {
+		if answer.QuestionID == questionID {
+			return answer, true
+		}
+	}
+	return AnswerResult{}, false
+}
+
+// IsCorrect reports whether answer is right for q, accepting the option set,
+// the exact stored answer or one the host accepted
+func IsCorrect(q types.Question, answer string) bool {
+	return q.Accepts(answer)
+}
+
+// BuildResults collects the standings, answers and response times of a game
+func BuildResults(game *types.GameState) Results {
+	standings := Standings(game)
+
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	results := Results{
+		GameID:    game.ID,
+		Name:      game.Name,
+		StartTime: game.StartTime,
+		EndTime:   game.EndTime,
+		Ended:     !game.IsActive && !game.EndTime.IsZero(),
+		Questions: make([]QuestionResult, len(game.Questions)),
+		Rounds:    game.Rounds,
+		Players:   make([]PlayerResult, 0, len(standings)),
+	}
+	index := make(map[int]int, len(game.Questions))
+	for i, q := range game.Questions {
+		results.Questions[i] = QuestionResult{Question: q, Voided: game.Voided[q.ID]}
+		index[q.ID] = i
+	}
+	timed := make([]int64, len(game.Questions))
+
+	for i, standing := range standings {
+		player, ok := game.Players[standing.PlayerID]
+		if !ok {
+			continue
+		}
+		result := PlayerResult{
+			Standing:    standing,
+			Tied:        tiedAt(standings, i),
+			Answers:     []AnswerResult{},
+			RoundScores: RoundScores(game, player),
+			Adjustments: player.Adjustments,
+		}
+		var totalMs, timedAnswers int64
+		answers := make(map[int]string, len(player.Answers))
+		for questionID, answer := range player.GetAllAnswers() {
+			answers[questionID] = answer
+		}
+		for questionID := range player.Rulings {
+			if _, answered := answers[questionID]; !answered {
+				// The host credited a question the player never answered
+				answers[questionID] = ""
+			}
+		}
+		for questionID, answer := range answers {
+			_, ruled := player.Rulings[questionID]
+			answerResult := AnswerResult{QuestionID: questionID, Answer: answer, ResponseMs: player.ResponseTimes[questionID], Ruled: ruled}
+			if qi, known := index[questionID]; known {
+				q := &results.Questions[qi]
+				answerResult.Correct = player.IsRight(&q.Question)
+				if _, gave := player.Answers[questionID]; gave {
+					q.Answered++
+				}
+				if answerResult.Correct {
+					q.Right++
+				}
+				if answerResult.ResponseMs > 0 {
+					q.AvgResponseMs += answerResult.ResponseMs
+					timed[qi]++
+				}
+			}
+			if answerResult.Correct && !game.Voided[questionID] {
+				result.Correct++
+			}
+			if answerResult.ResponseMs > 0 {
+				totalMs += answerResult.ResponseMs
+				timedAnswers++
+			}
+			result.Answers = append(result.Answers, answerResult)
+		}
+		if timedAnswers > 0 {
+			result.AvgResponseMs = totalMs / timedAnswers
+		}
+		sort.Slice(result.Answers, func(i, j int) bool {
+			return result.Answers[i].QuestionID < result.Answers[j].QuestionID
+		})
+		results.Players = append(results.Players, result)
+	}
+	for i := range results.Questions {
+		if timed[i] > 0 {
+			results.Questions[i].AvgResponseMs /= timed[i]
+		}
+	}
+	return results
+}
+
+// WriteJSON writes the results as indented JSON
+func (r Results) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per player: rank, name, score and totals, the
+// points from each round, then the answer, correctness and response time for
+// each question in order
+func (r Results) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"rank", "tied", "player_id", "name", "score", "correct", "avg_response_ms"}
+	for i := range r.Rounds {
+		header = append(header, fmt.Sprintf("round_%d_points", i+1))
+	}
+	for _, q := range r.Questions {
+		id := "q" + strconv.Itoa(q.ID)
+		header = append(header, id, id+"_correct", id+"_response_ms")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, player := range r.Players {
+		row := []string{
+			strconv.Itoa(player.Rank),
+			strconv.FormatBool(player.Tied),
+			player.PlayerID,
+			csvText(player.Name),
+			strconv.Itoa(player.Score),
+			strconv.Itoa(player.Correct),
+			strconv.FormatInt(player.AvgResponseMs, 10),
+		}
+		for _, points := range player.RoundScores {
+			row = append(row, strconv.Itoa(points))
+		}
+		for _, q := range r.Questions {
+			answer, ok := player.Answer(q.ID)
+			if !ok {
+				row = append(row, "", "", "")
+				continue
+			}
+			responseMs := ""
+			if answer.ResponseMs > 0 {
+				responseMs = strconv.FormatInt(answer.ResponseMs, 10)
+			}
+			row = append(row, csvText(answer.Answer), strconv.FormatBool(answer.Correct), responseMs)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText quotes text players typed so a spreadsheet shows it rather than
+// running it as a formula, as it would a name like "=HYPERLINK(...)"
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package game
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Pat", "Pat"},
+		{"", ""},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tTab", "'\tTab"},
+		{"Ann = Bob", "Ann = Bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := csvText(tt.in); got != tt.want {
+				t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	game := viewGame()
+	pat := game.Players["p"]
+	pat.Answers = map[int]string{1: "1", 2: "1"}
+	pat.ResponseTimes = map[int]int64{1: 1500}
+	guest := game.Players["g"]
+	guest.Name = "=cmd()"
+	guest.Answers = map[int]string{2: "2"}
+	guest.Rulings = map[int]bool{1: true} // credited for a question they missed
+	game.RecomputeScores()
+
+	var buf bytes.Buffer
+	if err := BuildResults(game).WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"rank", "tied", "player_id", "name", "score", "correct", "avg_response_ms", "round_1_points", "round_2_points",
+			"q1", "q1_correct", "q1_response_ms", "q2", "q2_correct", "q2_response_ms"},
+		{"1", "false", "g", "'=cmd()", "20", "2", "0", "10", "10", "", "true", "", "2", "true", ""},
+		{"2", "false", "p", "Pat", "10", "1", "1500", "10", "0", "1", "true", "1500", "1", "false", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
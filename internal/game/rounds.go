@@ -0,0 +1,167 @@
+package game
+
+import (
+	"fmt"
+	"richetechguy/internal/types"
+	"time"
+)
+
+// RoundPack is a round as written in a question pack, with its questions
+// inline rather than by ID
+type RoundPack struct {
+	types.Round
+	Questions []types.Question `json:"questions"`
+}
+
+// BuildRounds checks a pack's rounds and lays their questions out as one
+// deck, numbered from 1. Questions without a time limit get their round's.
+func BuildRounds(packs []RoundPack) ([]types.Question, []types.Round, error) {
+	var questions []types.Question
+	rounds := make([]types.Round, 0, len(packs))
+	for i, pack := range packs {
+		round := pack.Round
+		switch {
+		case round.Name == "":
+			return nil, nil, fmt.Errorf("round %d: a name is required", i+1)
+		case len(pack.Questions) == 0:
+			return nil, nil, fmt.Errorf("round %d: no questions", i+1)
+		case round.Points < 0 || round.TimeLimit < 0 || round.Intermission < 0:
+			return nil, nil, fmt.Errorf("round %d: points, time limit and intermission can't be negative", i+1)
+		case !round.Scoring.IsValid():
+			return nil, nil, fmt.Errorf("round %d: scoring must be standard, speed or penalty", i+1)
+		}
+		if round.Scoring == "" {
+			round.Scoring = types.ScoringStandard
+		}
+		round.QuestionIDs = make([]int, 0, len(pack.Questions))
+		for j, q := range pack.Questions {
+			if err := ValidateQuestion(q); err != nil {
+				return nil, nil, fmt.Errorf("round %d, question %d: %w", i+1, j+1, err)
+			}
+			q.ID = len(questions) + 1
+			if q.TimeLimit == 0 {
+				q.TimeLimit = round.TimeLimit
+			}
+			questions = append(questions, q)
+			round.QuestionIDs = append(round.QuestionIDs, q.ID)
+		}
+		rounds = append(rounds, round)
+	}
+	return questions, rounds, nil
+}
+
+// DescribeRound sums up a round's rules, like "80s Movies · 10 pts · 20s timer"
+func DescribeRound(round types.Round) string {
+	desc := ""
+	if round.Category != "" {
+		desc = round.Category + " · "
+	}
+	desc += fmt.Sprintf("%d pts", round.PointsPerAnswer())
+	if round.TimeLimit > 0 {
+		desc += fmt.Sprintf(" · %ds timer", round.TimeLimit)
+	}
+	switch round.Scoring {
+	case types.ScoringSpeed:
+		desc += " · speed bonus"
+	case types.ScoringPenalty:
+		desc += " · wrong answers lose points"
+	}
+	return desc
+}
+
+// RoundProgress is how far a game has got through one of its rounds
+type RoundProgress struct {
+	types.Round
+	Index   int  `json:"index"`
+	Opened  int  `json:"opened"` // questions opened so far
+	Current bool `json:"current"`
+	Done    bool `json:"done"`
+}
+
+// roundProgress reports each round of a game. Callers hold the game's lock.
+func roundProgress(game *types.GameState) []RoundProgress {
+	opened := make(map[int]bool, game.Round)
+	for i := 0; i < game.Round && i < len(game.Questions); i++ {
+		opened[game.Questions[i].ID] = true
+	}
+	current := currentRound(game)
+	progress := make([]RoundProgress, len(game.Rounds))
+	for i, round := range game.Rounds {
+		progress[i] = RoundProgress{Round: round, Index: i, Current: i == current}
+		for _, id := range round.QuestionIDs {
+			if opened[id] {
+				progress[i].Opened++
+			}
+		}
+		progress[i].Done = progress[i].Opened == len(round.QuestionIDs) && (i != current || !game.IntermissionUntil.IsZero() || !game.IsActive)
+	}
+	return progress
+}
+
+// currentRound is the index of the round the latest question opened belongs
+// to, or -1 before the first question or in a game without rounds. Callers
+// hold the game's lock.
+func currentRound(game *types.GameState) int {
+	if game.Round == 0 || game.Round > len(game.Questions) {
+		return -1
+	}
+	return game.RoundOf(game.Questions[game.Round-1].ID)
+}
+
+// RoundScores is what each of a player's rounds scored them, in order,
+// leaving out void questions and the host's adjustments. Callers hold the
+// game's lock.
+func RoundScores(game *types.GameState, player *types.Player) []int {
+	if len(game.Rounds) == 0 {
+		return nil
+	}
+	scores := make([]int, len(game.Rounds))
+	for i := range game.Questions {
+		q := &game.Questions[i]
+		if r := game.RoundOf(q.ID); r >= 0 && !game.Voided[q.ID] {
+			scores[r] += game.QuestionPoints(player, q)
+		}
+	}
+	return scores
+}
+
+// Intermission is the break between two rounds
+type Intermission struct {
+	Round     types.Round  `json:"round"`          // the round that just finished
+	Next      *types.Round `json:"next,omitempty"` // the round coming up
+	Until     time.Time    `json:"until"`
+	Standings []Ranking    `json:"standings"` // ranked by points in the finished round
+}
+
+// BuildIntermission describes the break a game is in, or returns nil when it
+// isn't in one or the break is over
+func BuildIntermission(game *types.GameState) *Intermission {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	r := currentRound(game)
+	if game.IntermissionUntil.IsZero() || !time.Now().Before(game.IntermissionUntil) || r < 0 {
+		return nil
+	}
+	intermission := &Intermission{Round: game.Rounds[r], Until: game.IntermissionUntil}
+	if r+1 < len(game.Rounds) {
+		next := game.Rounds[r+1]
+		intermission.Next = &next
+	}
+
+	standings := make([]Standing, 0, len(game.Players))
+	for _, player := range game.Players {
+		standings = append(standings, Standing{PlayerID: player.ID, Name: player.Name, Score: RoundScores(game, player)[r]})
+	}
+	rankStandings(standings)
+	for i := range standings {
+		intermission.Standings = append(intermission.Standings, Ranking{
+			Rank:     standings[i].Rank,
+			PlayerID: standings[i].PlayerID,
+			Name:     standings[i].Name,
+			Score:    standings[i].Score,
+			Tied:     tiedAt(standings, i),
+		})
+	}
+	return intermission
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"reflect"
+	"richetechguy/internal/types"
+	"testing"
+)
+
+func TestBuildRounds(t *testing.T) {
+	question := func(text string) types.Question {
+		return types.Question{Type: types.SingleChoice, Text: text, Options: []string{"a", "b"}, Correct: "1"}
+	}
+	timed := question("Timed?")
+	timed.TimeLimit = 5
+
+	tests := []struct {
+		name      string
+		packs     []RoundPack
+		wantIDs   [][]int // each round's question IDs
+		wantTimes []int   // each question's time limit
+		wantErr   bool
+	}{
+		{
+			name: "two rounds",
+			packs: []RoundPack{
+				{Round: types.Round{Name: "One", TimeLimit: 20}, Questions: []types.Question{question("A?"), timed}},
+				{Round: types.Round{Name: "Two", Scoring: types.ScoringSpeed}, Questions: []types.Question{question("C?")}},
+			},
+			wantIDs:   [][]int{{1, 2}, {3}},
+			wantTimes: []int{20, 5, 0},
+		},
+		{
+			name:    "no name",
+			packs:   []RoundPack{{Questions: []types.Question{question("A?")}}},
+			wantErr: true,
+		},
+		{
+			name:    "no questions",
+			packs:   []RoundPack{{Round: types.Round{Name: "Empty"}}},
+			wantErr: true,
+		},
+		{
+			name:    "negative points",
+			packs:   []RoundPack{{Round: types.Round{Name: "One", Points: -5}, Questions: []types.Question{question("A?")}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown scoring",
+			packs:   []RoundPack{{Round: types.Round{Name: "One", Scoring: "double"}, Questions: []types.Question{question("A?")}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid question",
+			packs:   []RoundPack{{Round: types.Round{Name: "One"}, Questions: []types.Question{{Text: "No options?"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, rounds, err := BuildRounds(tt.packs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var ids [][]int
+			for _, round := range rounds {
+				ids = append(ids, round.QuestionIDs)
+				if round.Scoring == "" {
+					t.Errorf("round %s has no scoring, want standard by default", round.Name)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("question IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			var times []int
+			for _, q := range questions {
+				times = append(times, q.TimeLimit)
+			}
+			if !reflect.DeepEqual(times, tt.wantTimes) {
+				t.Errorf("time limits = %v, want %v", times, tt.wantTimes)
+			}
+		})
+	}
+}
+
+func TestRoundScores(t *testing.T) {
+	game := viewGame()
+	game.Rounds[1].Points = 30
+	player := game.Players["p"]
+	player.Answers[1] = "1"
+	player.Answers[2] = "2"
+	player.Adjustments = []types.ScoreAdjustment{{Delta: 5}}
+
+	if got, want := RoundScores(game, player), []int{10, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RoundScores = %v, want %v", got, want)
+	}
+	game.Voided = map[int]bool{2: true}
+	if got, want := RoundScores(game, player), []int{10, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with question 2 void, RoundScores = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package game
+
+import (
+	"fmt"
+	"richetechguy/internal/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReasonLength caps the reason given for a manual score change
+const maxReasonLength = 200
+
+// ScoreLine is one source of a player's points
+type ScoreLine struct {
+	QuestionID int    `json:"questionId,omitempty"` // zero for manual adjustments
+	Label      string `json:"label"`                // the question, or the adjustment's reason
+	Answer     string `json:"answer,omitempty"`
+	AnswerText string `json:"answerText,omitempty"` // the answer's option text
+	Right      bool   `json:"right"`
+	Points     int    `json:"points"`
+	Note       string `json:"note,omitempty"` // why the points aren't what the answer alone would give
+}
+
+// ScoreBreakdown explains how a player's score adds up
+type ScoreBreakdown struct {
+	PlayerID string      `json:"playerId"`
+	Name     string      `json:"name"`
+	Score    int         `json:"score"`
+	Lines    []ScoreLine `json:"lines"`
+}
+
+// BuildBreakdown lists the points a player got for each question and from
+// each manual adjustment
+func BuildBreakdown(game *types.GameState, playerID string) (ScoreBreakdown, error) {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	player, ok := game.Players[playerID]
+	if !ok {
+		return ScoreBreakdown{}, fmt.Errorf("player not found")
+	}
+	breakdown := ScoreBreakdown{PlayerID: player.ID, Name: player.Name, Score: player.Score, Lines: []ScoreLine{}}
+	for i := range game.Questions {
+		q := &game.Questions[i]
+		line := ScoreLine{QuestionID: q.ID, Label: fmt.Sprintf("Q%d. %s", i+1, q.Text), Right: player.IsRight(q)}
+		answer, answered := player.Answers[q.ID]
+		if answered {
+			line.Answer = answer
+			line.AnswerText = answerText(q, answer)
+		}
+		ruling, ruled := player.Rulings[q.ID]
+		switch {
+		case ruled && ruling:
+			line.Note = "marked right by the host"
+		case ruled:
+			line.Note = "marked wrong by the host"
+		case answered && line.Right && !q.ValidateAnswer(answer) && answer != q.Correct:
+			line.Note = "accepted by the host"
+		}
+		line.Points = game.QuestionPoints(player, q)
+		if r := game.RoundOf(q.ID); r >= 0 {
+			line.Label = game.Rounds[r].Name + " · " + line.Label
+		}
+		if game.Voided[q.ID] {
+			line.Points = 0
+			line.Note = "question void"
+		}
+		breakdown.Lines = append(breakdown.Lines, line)
+	}
+	for _, adjustment := range player.Adjustments {
+		breakdown.Lines = append(breakdown.Lines, ScoreLine{
+			Label:  adjustment.Reason,
+			Points: adjustment.Delta,
+			Note:   "adjusted " + adjustment.At.Format("15:04"),
+		})
+	}
+	return breakdown, nil
+}
+
+// AdjustScore awards (or, with a negative delta, deducts) points by hand
+func (gm *GameManager) AdjustScore(gameID, playerID string, delta int, reason string) (*types.GameState, int, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, 0, err
+	}
+	reason = strings.TrimSpace(reason)
+	switch {
+	case delta == 0:
+		return nil, 0, fmt.Errorf("an adjustment needs a non-zero number of points")
+	case reason == "":
+		return nil, 0, fmt.Errorf("give a reason for the adjustment")
+	case utf8.RuneCountInString(reason) > maxReasonLength:
+		return nil, 0, fmt.Errorf("the reason must be at most %d characters", maxReasonLength)
+	}
+	score, err := game.AdjustScore(playerID, delta, reason)
+	if err != nil {
+		return nil, 0, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionAdjust, fmt.Sprintf("%s %+d: %s", gm.playerName(game, playerID), delta, reason))
+	return game, score, gm.Db.SaveGame(game)
+}
+
+// AcceptAnswer rules another answer to a question right, rescoring everyone
+// who gave it. answer is in the same 1-based option form as Question.Correct.
+func (gm *GameManager) AcceptAnswer(gameID string, questionID int, answer string) (*types.GameState, *types.Question, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, nil, err
+	}
+	game.Mu.RLock()
+	options := -1
+	for _, q := range game.Questions {
+		if q.ID == questionID {
+			options = len(q.Options)
+		}
+	}
+	game.Mu.RUnlock()
+	if options < 0 {
+		return nil, nil, fmt.Errorf("unknown question %d", questionID)
+	}
+	answer, err = normalizeAnswer(answer, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	question, err := game.AcceptAnswer(questionID, answer)
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionAccept, fmt.Sprintf("%s: %s", describeQuestion(*question), answer))
+	return game, question, gm.Db.SaveGame(game)
+}
+
+// RuleAnswer marks one player's answer to a question right or wrong
+func (gm *GameManager) RuleAnswer(gameID, playerID string, questionID int, right bool) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	question, err := game.RuleAnswer(playerID, questionID, right)
+	if err != nil {
+		return nil, err
+	}
+	ruling := "wrong"
+	if right {
+		ruling = "right"
+	}
+	gm.Sync(game)
+	gm.audit(game, ActionRule, fmt.Sprintf("%s on %s: %s", gm.playerName(game, playerID), describeQuestion(*question), ruling))
+	return game, gm.Db.SaveGame(game)
+}
+
+func (gm *GameManager) playerName(game *types.GameState, playerID string) string {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	if player, ok := game.Players[playerID]; ok {
+		return player.Name
+	}
+	return playerID
+}
+
+// answerText spells out an answer's options, like "Paris, Rome"
+func answerText(q *types.Question, answer string) string {
+	indexes := optionIndexes(answer, len(q.Options))
+	if len(indexes) == 0 {
+		return answer
+	}
+	texts := make([]string, len(indexes))
+	for i, n := range indexes {
+		texts[i] = q.Options[n]
+	}
+	return strings.Join(texts, ", ")
+}
+
+// normalizeAnswer checks an answer names real options and puts it in
+// ascending order, like "1,3"
+func normalizeAnswer(answer string, options int) (string, error) {
+	parts := strings.Split(answer, ",")
+	indexes := optionIndexes(answer, options)
+	if len(indexes) == 0 || len(indexes) != len(parts) {
+		return "", fmt.Errorf("answer must be option numbers between 1 and %d, like 2 or 1,3", options)
+	}
+	sort.Ints(indexes)
+	numbers := make([]string, len(indexes))
+	for i, n := range indexes {
+		numbers[i] = strconv.Itoa(n + 1)
+	}
+	return strings.Join(numbers, ","), nil
+}
@@ -0,0 +1,39 @@
+package game
+
+import "richetechguy/internal/types"
+
+// AddSpectator counts someone following a game from ip without playing in
+// it. Banned addresses can't watch either.
+func (gm *GameManager) AddSpectator(gameID string, ip string) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game.IsBanned("", ip) {
+		return nil, types.ErrBanned
+	}
+	game.Mu.Lock()
+	game.Spectators++
+	game.Mu.Unlock()
+
+	gm.Sync(game)
+	return game, nil
+}
+
+// RemoveSpectator stops counting a spectator whose socket closed
+func (gm *GameManager) RemoveSpectator(game *types.GameState) {
+	game.Mu.Lock()
+	if game.Spectators > 0 {
+		game.Spectators--
+	}
+	game.Mu.Unlock()
+
+	gm.Sync(game)
+}
+
+// SpectatorCount returns how many spectators are following a game
+func SpectatorCount(game *types.GameState) int {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	return game.Spectators
+}
@@ -0,0 +1,234 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"richetechguy/internal/broker"
+	"richetechguy/internal/types"
+	"slices"
+	"sync"
+	"time"
+)
+
+// GameSnapshot is the form a GameState is replicated in between instances
+type GameSnapshot struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	IsActive        bool             `json:"isActive"`
+	IsPaused        bool             `json:"isPaused"`
+	IsLocked        bool             `json:"isLocked"`
+	IsRevealed      bool             `json:"isRevealed"`
+	SelfPaced       bool             `json:"selfPaced"`
+	Round           int              `json:"round"`
+	CurrentQuestion *types.Question  `json:"currentQuestion,omitempty"`
+	Questions       []types.Question `json:"questions"`
+	StartTime       time.Time        `json:"startTime"`
+	EndTime         time.Time        `json:"endTime"`
+	Players         []*types.Player  `json:"players"`
+	// QuestionOpenedAt lets every instance time answers to the current question
+	QuestionOpenedAt time.Time            `json:"questionOpenedAt"`
+	PresenterToken   string               `json:"presenterToken"`
+	RanksBefore      map[string]int       `json:"ranksBefore,omitempty"`
+	Theme            types.Theme          `json:"theme"`
+	PausedAt         time.Time            `json:"pausedAt"`
+	ExtraTime        int                  `json:"extraTime,omitempty"`
+	Voided           map[int]bool         `json:"voided,omitempty"`
+	Bans             []types.Ban          `json:"bans,omitempty"`
+	LateJoin         types.LateJoinPolicy `json:"lateJoin,omitempty"`
+	Spectators       int                  `json:"spectators,omitempty"`
+	Rounds           []types.Round        `json:"rounds,omitempty"`
+	// IntermissionUntil is set while the game is between rounds
+	IntermissionUntil time.Time `json:"intermissionUntil"`
+	// PlayerIPs maps player ID to the address they joined from, which Player
+	// leaves out of its JSON, so every instance can ban by address
+	PlayerIPs map[string]string `json:"playerIps,omitempty"`
+}
+
+// gameEvent is the payload published on broker.TopicGames
+type gameEvent struct {
+	Cleared bool          `json:"cleared,omitempty"`
+	Deleted []string      `json:"deleted,omitempty"`
+	Game    *GameSnapshot `json:"game,omitempty"`
+	// Blocklist carries the dashboard's blocked name words when they change
+	Blocklist *[]string `json:"blocklist,omitempty"`
+}
+
+// UseBroker replaces the in-process broker, e.g. with one shared through the
+// database so several instances can serve the same games.
+func (gm *GameManager) UseBroker(b broker.Broker) {
+	gm.Broker = b
+	b.Subscribe(broker.TopicGames, gm.handleGameEvent)
+}
+
+// Sync publishes the current state of a game to the other instances. Call it
+// after any change players or admins on another instance need to see.
+func (gm *GameManager) Sync(game *types.GameState) {
+	if gm.Broker == nil || game == nil {
+		return
+	}
+	gm.publishGameEvent(gameEvent{Game: snapshotGame(game)})
+}
+
+func (gm *GameManager) publishGameEvent(event gameEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("encoding game event failed", "err", err)
+		return
+	}
+	if err := gm.Broker.Publish(broker.TopicGames, payload); err != nil {
+		slog.Error("publishing game event failed", "err", err)
+	}
+}
+
+// snapshotGame copies a game for publishing. Everything is copied under the
+// lock, since the snapshot is encoded after it is released.
+func snapshotGame(game *types.GameState) *GameSnapshot {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	players := make([]*types.Player, 0, len(game.Players))
+	ips := make(map[string]string, len(game.Players))
+	for _, player := range game.Players {
+		players = append(players, player.Copy())
+		if player.IP != "" {
+			ips[player.ID] = player.IP
+		}
+	}
+	questions := types.CopyQuestions(game.Questions)
+	var current *types.Question
+	if game.CurrentQuestion != nil {
+		current = &types.CopyQuestions([]types.Question{*game.CurrentQuestion})[0]
+	}
+	return &GameSnapshot{
+		ID:                game.ID,
+		Name:              game.Name,
+		IsActive:          game.IsActive,
+		IsPaused:          game.IsPaused,
+		IsLocked:          game.IsLocked,
+		IsRevealed:        game.IsRevealed,
+		SelfPaced:         game.SelfPaced,
+		Round:             game.Round,
+		CurrentQuestion:   current,
+		Questions:         questions,
+		StartTime:         game.StartTime,
+		EndTime:           game.EndTime,
+		Players:           players,
+		QuestionOpenedAt:  game.QuestionOpenedAt,
+		PresenterToken:    game.PresenterToken,
+		RanksBefore:       maps.Clone(game.RanksBefore),
+		Theme:             game.Theme,
+		PausedAt:          game.PausedAt,
+		ExtraTime:         game.ExtraTime,
+		Voided:            maps.Clone(game.Voided),
+		Bans:              slices.Clone(game.Bans),
+		LateJoin:          game.LateJoin,
+		Spectators:        game.Spectators,
+		Rounds:            slices.Clone(game.Rounds),
+		IntermissionUntil: game.IntermissionUntil,
+		PlayerIPs:         ips,
+	}
+}
+
+func (gm *GameManager) handleGameEvent(event broker.Event) {
+	// Our own changes are already in memory
+	if event.Origin == gm.Broker.InstanceID() {
+		return
+	}
+
+	var payload gameEvent
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		slog.Error("decoding game event failed", "event_id", event.ID, "err", err)
+		return
+	}
+
+	if payload.Cleared {
+		gm.mu.Lock()
+		gm.Games = make(map[string]*types.GameState)
+		gm.mu.Unlock()
+		return
+	}
+	if len(payload.Deleted) > 0 {
+		gm.mu.Lock()
+		for _, id := range payload.Deleted {
+			delete(gm.Games, id)
+		}
+		gm.mu.Unlock()
+	}
+	if payload.Game != nil {
+		gm.applySnapshot(payload.Game)
+	}
+	if payload.Blocklist != nil && gm.Blocklist != nil {
+		gm.Blocklist.SetCustom(*payload.Blocklist)
+	}
+}
+
+// applySnapshot merges a game published by another instance into memory.
+// Connections stay where they are and players' answers are merged with the
+// ones recorded here; the latest snapshot wins for everything else.
+func (gm *GameManager) applySnapshot(snapshot *GameSnapshot) {
+	gm.mu.Lock()
+	game, exists := gm.Games[snapshot.ID]
+	if !exists {
+		game = &types.GameState{
+			ID:      snapshot.ID,
+			Players: make(map[string]*types.Player),
+			Mu:      sync.RWMutex{},
+		}
+		gm.Games[snapshot.ID] = game
+	}
+	gm.mu.Unlock()
+
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+
+	game.Name = snapshot.Name
+	game.IsActive = snapshot.IsActive
+	game.IsPaused = snapshot.IsPaused
+	game.IsLocked = snapshot.IsLocked
+	game.IsRevealed = snapshot.IsRevealed
+	game.SelfPaced = snapshot.SelfPaced
+	game.Round = snapshot.Round
+	game.CurrentQuestion = snapshot.CurrentQuestion
+	game.Questions = snapshot.Questions
+	game.StartTime = snapshot.StartTime
+	game.EndTime = snapshot.EndTime
+	game.QuestionOpenedAt = snapshot.QuestionOpenedAt
+	game.PresenterToken = snapshot.PresenterToken
+	game.RanksBefore = snapshot.RanksBefore
+	game.Theme = snapshot.Theme
+	game.PausedAt = snapshot.PausedAt
+	game.ExtraTime = snapshot.ExtraTime
+	game.Voided = snapshot.Voided
+	game.Bans = snapshot.Bans
+	game.LateJoin = snapshot.LateJoin
+	game.Spectators = snapshot.Spectators
+	game.Rounds = snapshot.Rounds
+	game.IntermissionUntil = snapshot.IntermissionUntil
+
+	seen := make(map[string]bool, len(snapshot.Players))
+	for _, remote := range snapshot.Players {
+		seen[remote.ID] = true
+		if ip := snapshot.PlayerIPs[remote.ID]; ip != "" {
+			remote.IP = ip
+		}
+		local, exists := game.Players[remote.ID]
+		if !exists {
+			game.Players[remote.ID] = remote
+			continue
+		}
+		if remote.IP != "" {
+			local.IP = remote.IP
+		}
+		local.Name = remote.Name
+		local.Anonymous = remote.Anonymous
+		game.MergePlayer(local, remote)
+		local.SyncPresence(remote.Status, remote.LastSeen)
+	}
+	for id, local := range game.Players {
+		// Keep players connected here even if the other instance hasn't seen them yet
+		if !seen[id] && !local.IsConnected() {
+			delete(game.Players, id)
+		}
+	}
+}
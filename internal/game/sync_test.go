@@ -0,0 +1,137 @@
+package game
+
+import (
+	"richetechguy/internal/broker"
+	"richetechguy/internal/types"
+	"sync"
+	"testing"
+)
+
+// pairBroker links two instances: each publishes under its own ID and both
+// receive every event, like two servers sharing the database broker
+type pairBroker struct {
+	id   string
+	peer *pairBroker
+	mu   sync.Mutex
+	subs map[string][]broker.Handler
+}
+
+func newPair() (*pairBroker, *pairBroker) {
+	a, b := &pairBroker{id: "a"}, &pairBroker{id: "b"}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func (p *pairBroker) InstanceID() string { return p.id }
+func (p *pairBroker) Close() error       { return nil }
+
+func (p *pairBroker) Subscribe(topic string, handler broker.Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.subs == nil {
+		p.subs = make(map[string][]broker.Handler)
+	}
+	p.subs[topic] = append(p.subs[topic], handler)
+}
+
+func (p *pairBroker) Publish(topic string, payload []byte) error {
+	event := broker.Event{Topic: topic, Origin: p.id, Payload: payload}
+	for _, b := range []*pairBroker{p, p.peer} {
+		b.mu.Lock()
+		handlers := b.subs[topic]
+		b.mu.Unlock()
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+	return nil
+}
+
+// syncedGame opens the first question of the same game, with players Ann and
+// Bob, on two instances linked by a broker
+func syncedGame() (*GameManager, *GameManager, string) {
+	game := viewGame()
+	open(game, 1)
+	game.Players = map[string]*types.Player{
+		"ann": {ID: "ann", Name: "Ann", Answers: map[int]string{}},
+		"bob": {ID: "bob", Name: "Bob", Answers: map[int]string{}},
+	}
+	a, b := newPair()
+	first := &GameManager{Games: map[string]*types.GameState{}}
+	second := &GameManager{Games: map[string]*types.GameState{}}
+	first.UseBroker(a)
+	second.UseBroker(b)
+	first.Games[game.ID] = game
+	first.Sync(game)
+	return first, second, game.ID
+}
+
+func TestSyncMergesPlayers(t *testing.T) {
+	tests := []struct {
+		name        string
+		first       func(game *types.GameState) error // a change made on the first instance
+		second      func(game *types.GameState) error // and one made on the second
+		wantAnswers map[string]string                 // player ID to their answer to question 1
+		wantScores  map[string]int
+	}{
+		{
+			name:        "both answer",
+			first:       func(game *types.GameState) error { return game.SubmitAnswer("ann", "1") },
+			second:      func(game *types.GameState) error { return game.SubmitAnswer("bob", "1") },
+			wantAnswers: map[string]string{"ann": "1", "bob": "1"},
+			wantScores:  map[string]int{"ann": 10, "bob": 10},
+		},
+		{
+			name:  "answer and adjustment",
+			first: func(game *types.GameState) error { return game.SubmitAnswer("ann", "1") },
+			second: func(game *types.GameState) error {
+				_, err := game.AdjustScore("ann", 5, "bonus")
+				return err
+			},
+			wantAnswers: map[string]string{"ann": "1"},
+			wantScores:  map[string]int{"ann": 15, "bob": 0},
+		},
+		{
+			name:  "answer and ruling",
+			first: func(game *types.GameState) error { return game.SubmitAnswer("bob", "2") },
+			second: func(game *types.GameState) error {
+				_, err := game.RuleAnswer("ann", 1, true)
+				return err
+			},
+			wantAnswers: map[string]string{"bob": "2"},
+			wantScores:  map[string]int{"ann": 10, "bob": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second, id := syncedGame()
+			gameA, gameB := first.Games[id], second.Games[id]
+			if gameB == nil {
+				t.Fatal("game not replicated to the second instance")
+			}
+
+			// Both change the game before either hears from the other
+			if err := tt.first(gameA); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.second(gameB); err != nil {
+				t.Fatal(err)
+			}
+			second.Sync(gameB)
+			first.Sync(gameA)
+
+			for i, game := range []*types.GameState{gameA, gameB} {
+				for playerID, player := range game.Players {
+					answer, answered := player.Answers[1]
+					want, wantAnswered := tt.wantAnswers[playerID]
+					if answer != want || answered != wantAnswered || len(player.Answers) > 1 {
+						t.Errorf("instance %d: %s has answers %v, want %q", i+1, playerID, player.Answers, want)
+					}
+					if player.Score != tt.wantScores[playerID] {
+						t.Errorf("instance %d: %s scored %d, want %d", i+1, playerID, player.Score, tt.wantScores[playerID])
+					}
+				}
+			}
+		})
+	}
+}
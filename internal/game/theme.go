@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"richetechguy/internal/types"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultGameName is what games are called when nobody names them
+const DefaultGameName = "Rookie of the Year"
+
+// DefaultTheme fills in whatever a game's theme leaves empty
+var DefaultTheme = types.Theme{
+	BackgroundURL:  "/static/bg.jpeg",
+	PrimaryColor:   "#3b82f6",
+	SecondaryColor: "#ffffff",
+	LinkText:       "Visit",
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ResolveTheme returns a game's theme with the defaults filled in. The title
+// defaults to the game's name; a nil game gets the default theme.
+func ResolveTheme(game *types.GameState) types.Theme {
+	theme := types.Theme{Title: DefaultGameName}
+	if game != nil {
+		game.Mu.RLock()
+		theme = game.Theme
+		if theme.Title == "" {
+			theme.Title = game.Name
+		}
+		game.Mu.RUnlock()
+	}
+	for _, field := range []struct {
+		value    *string
+		fallback string
+	}{
+		{&theme.BackgroundURL, DefaultTheme.BackgroundURL},
+		{&theme.PrimaryColor, DefaultTheme.PrimaryColor},
+		{&theme.SecondaryColor, DefaultTheme.SecondaryColor},
+		{&theme.LinkText, DefaultTheme.LinkText},
+	} {
+		if *field.value == "" {
+			*field.value = field.fallback
+		}
+	}
+	return theme
+}
+
+// ValidateTheme trims a theme and checks it is safe to put in a page: colors
+// must be hex and links must be http(s) URLs or paths on this site
+func ValidateTheme(theme types.Theme) (types.Theme, error) {
+	texts := []struct {
+		name  string
+		value *string
+		max   int
+	}{
+		{"title", &theme.Title, 80},
+		{"subtitle", &theme.Subtitle, 160},
+		{"link text", &theme.LinkText, 80},
+		{"logo URL", &theme.LogoURL, 2048},
+		{"background URL", &theme.BackgroundURL, 2048},
+		{"link URL", &theme.LinkURL, 2048},
+		{"primary color", &theme.PrimaryColor, 7},
+		{"secondary color", &theme.SecondaryColor, 7},
+	}
+	for _, text := range texts {
+		*text.value = strings.TrimSpace(*text.value)
+		if utf8.RuneCountInString(*text.value) > text.max {
+			return theme, fmt.Errorf("%s must be at most %d characters", text.name, text.max)
+		}
+	}
+	for name, color := range map[string]string{"primary color": theme.PrimaryColor, "secondary color": theme.SecondaryColor} {
+		if color != "" && !hexColor.MatchString(color) {
+			return theme, fmt.Errorf("%s must be a hex color like #3b82f6", name)
+		}
+	}
+	for name, link := range map[string]string{"logo URL": theme.LogoURL, "background URL": theme.BackgroundURL, "link URL": theme.LinkURL} {
+		if link != "" && !safeURL(link) {
+			return theme, fmt.Errorf("%s must be an http(s) URL or a path starting with /", name)
+		}
+	}
+	return theme, nil
+}
+
+// safeURL accepts absolute http(s) URLs and paths on this site. Anything
+// else, like javascript: URLs, could run in players' browsers.
+func safeURL(link string) bool {
+	if strings.ContainsAny(link, "'\\\"() \t\n") {
+		// These would break out of CSS url() values
+		return false
+	}
+	if strings.HasPrefix(link, "/") {
+		return !strings.HasPrefix(link, "//")
+	}
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// SetTheme validates and stores a game's theme
+func (gm *GameManager) SetTheme(gameID string, theme types.Theme) (*types.GameState, error) {
+	game, err := gm.GetGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	theme, err = ValidateTheme(theme)
+	if err != nil {
+		return nil, err
+	}
+	game.Mu.Lock()
+	game.Theme = theme
+	game.Mu.Unlock()
+
+	gm.Sync(game)
+	return game, gm.Db.SaveGame(game)
+}
@@ -0,0 +1,157 @@
+package game
+
+import (
+	"richetechguy/internal/types"
+	"sort"
+	"time"
+)
+
+// Phase is the screen a player should be looking at
+type Phase string
+
+const (
+	PhaseLobby    Phase = "lobby"    // waiting for the host to start
+	PhaseReady    Phase = "ready"    // started, no question open yet
+	PhaseQuestion Phase = "question" // a question is open and unanswered
+	PhaseLocked   Phase = "locked"   // answered, or answers are closed
+	PhaseReveal   Phase = "reveal"   // the correct answer is shown
+	PhasePaused   Phase = "paused"
+	// PhaseIntermission is the break between rounds, showing the round's standings
+	PhaseIntermission Phase = "intermission"
+	PhaseFinished     Phase = "finished" // the game ended, or a self-paced deck is done
+)
+
+// leaderboardSize is how many players the between-round leaderboard lists;
+// players further down also see the places either side of them
+const (
+	leaderboardSize  = 5
+	leaderboardReach = 1
+)
+
+// PlayerView is everything a player's screen shows at one moment. Phase logic
+// lives here so clients only have to swap in the rendered HTML.
+type PlayerView struct {
+	Phase    Phase
+	GameID   string
+	GameName string
+	PlayerID string
+	Name     string
+
+	Question *types.Question
+	Number   int // 1-based position of Question in the deck
+	Total    int
+	Round    string    // the name of Question's round, in games with rounds
+	Deadline time.Time // when Question stops taking answers; zero without a countdown
+
+	Intermission *Intermission
+
+	Answer    string // the player's answer to Question, if any
+	Answered  bool
+	Correct   bool // set once the answer is revealed
+	SelfPaced bool
+	Ended     bool
+
+	Theme types.Theme
+
+	Players     []*types.Player // the lobby roster, without anonymous players
+	Leaderboard []Ranking       // top of the table
+	Around      []Ranking       // the player's neighbours, when they're below the top
+	Me          *Ranking        // the player's own ranking
+}
+
+// BuildPlayerView works out what a player should see right now
+func BuildPlayerView(game *types.GameState, playerID string) PlayerView {
+	leaderboard := BuildLeaderboard(game)
+	theme := ResolveTheme(game)
+	intermission := BuildIntermission(game)
+
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	view := PlayerView{
+		GameID:    game.ID,
+		GameName:  game.Name,
+		PlayerID:  playerID,
+		Total:     len(game.Questions),
+		SelfPaced: game.SelfPaced,
+		Theme:     theme,
+	}
+	view.Leaderboard = leaderboard.Top(leaderboardSize)
+	if me, ok := leaderboard.Find(playerID); ok {
+		view.Me = &me
+		if me.Rank > leaderboardSize {
+			view.Around = leaderboard.Around(playerID, leaderboardReach)
+		}
+	}
+
+	player := game.Players[playerID]
+	if player != nil {
+		view.Name = player.Name
+	}
+
+	switch {
+	case !game.IsActive && game.EndTime.IsZero():
+		view.Phase = PhaseLobby
+		for _, p := range game.Players {
+			if !p.Anonymous {
+				view.Players = append(view.Players, p)
+			}
+		}
+		sort.Slice(view.Players, func(i, j int) bool { return view.Players[i].Name < view.Players[j].Name })
+	case !game.IsActive:
+		view.Phase = PhaseFinished
+		view.Ended = true
+	case game.IsPaused:
+		view.Phase = PhasePaused
+	case intermission != nil:
+		view.Phase = PhaseIntermission
+		view.Intermission = intermission
+	case game.SelfPaced:
+		// Players work through the deck in order, one unanswered question at a time
+		view.Phase = PhaseFinished
+		for i := range game.Questions {
+			if _, answered := answerOf(player, game.Questions[i].ID); !answered {
+				view.Phase = PhaseQuestion
+				view.Question = &game.Questions[i]
+				view.Number = i + 1
+				view.Round = roundName(game, view.Question.ID)
+				break
+			}
+		}
+	case game.CurrentQuestion == nil:
+		view.Phase = PhaseReady
+	default:
+		view.Question = game.CurrentQuestion
+		view.Number = game.Round
+		view.Round = roundName(game, game.CurrentQuestion.ID)
+		view.Answer, view.Answered = answerOf(player, game.CurrentQuestion.ID)
+		switch {
+		case game.IsRevealed:
+			view.Phase = PhaseReveal
+			view.Correct = player != nil && player.IsRight(game.CurrentQuestion)
+		case game.IsLocked || view.Answered:
+			view.Phase = PhaseLocked
+		default:
+			view.Phase = PhaseQuestion
+			view.Deadline = game.Deadline()
+		}
+	}
+	return view
+}
+
+// roundName names the round a question is in, or returns "" when the game
+// has no rounds. Callers hold the game's lock.
+func roundName(game *types.GameState, questionID int) string {
+	if r := game.RoundOf(questionID); r >= 0 {
+		return game.Rounds[r].Name
+	}
+	return ""
+}
+
+func answerOf(player *types.Player, questionID int) (string, bool) {
+	if player == nil {
+		return "", false
+	}
+	answer, ok := player.Answers[questionID]
+	return answer, ok
+}
@@ -0,0 +1,215 @@
+package game
+
+import (
+	"richetechguy/internal/types"
+	"testing"
+	"time"
+)
+
+// viewGame is a two question game, split into two rounds, with one player
+// "p" who answered nothing yet
+func viewGame() *types.GameState {
+	game := NewGameState("test")
+	game.Questions = []types.Question{
+		{ID: 1, Type: types.SingleChoice, Text: "One?", Options: []string{"a", "b"}, Correct: "1", TimeLimit: 20},
+		{ID: 2, Type: types.SingleChoice, Text: "Two?", Options: []string{"a", "b"}, Correct: "2"},
+	}
+	game.Rounds = []types.Round{
+		{Name: "First", Intermission: 30, QuestionIDs: []int{1}},
+		{Name: "Second", QuestionIDs: []int{2}},
+	}
+	game.Players["p"] = &types.Player{ID: "p", Name: "Pat", Answers: map[int]string{}}
+	game.Players["g"] = &types.Player{ID: "g", Name: "Guest 1", Anonymous: true, Answers: map[int]string{}}
+	return game
+}
+
+// open makes question n of the deck the current one
+func open(game *types.GameState, n int) {
+	game.IsActive = true
+	game.Round = n
+	game.CurrentQuestion = &game.Questions[n-1]
+	game.QuestionOpenedAt = time.Now()
+}
+
+func TestBuildPlayerView(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(game *types.GameState)
+		phase    Phase
+		question int // the ID of the question shown, or 0 for none
+		check    func(t *testing.T, view PlayerView)
+	}{
+		{
+			name:  "lobby",
+			setup: func(game *types.GameState) {},
+			phase: PhaseLobby,
+			check: func(t *testing.T, view PlayerView) {
+				if len(view.Players) != 1 || view.Players[0].Name != "Pat" {
+					t.Errorf("roster = %v, want only Pat without the anonymous guest", view.Players)
+				}
+			},
+		},
+		{
+			name:  "ready",
+			setup: func(game *types.GameState) { game.IsActive = true },
+			phase: PhaseReady,
+		},
+		{
+			name:     "question",
+			setup:    func(game *types.GameState) { open(game, 1) },
+			phase:    PhaseQuestion,
+			question: 1,
+			check: func(t *testing.T, view PlayerView) {
+				if view.Number != 1 || view.Total != 2 || view.Round != "First" {
+					t.Errorf("got question %d of %d in %q, want 1 of 2 in First", view.Number, view.Total, view.Round)
+				}
+				if view.Deadline.IsZero() {
+					t.Error("timed question has no deadline")
+				}
+			},
+		},
+		{
+			name: "answered",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.Players["p"].Answers[1] = "2"
+			},
+			phase:    PhaseLocked,
+			question: 1,
+			check: func(t *testing.T, view PlayerView) {
+				if !view.Answered || view.Answer != "2" {
+					t.Errorf("answer = %q, %v; want 2, true", view.Answer, view.Answered)
+				}
+			},
+		},
+		{
+			name: "locked unanswered",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.IsLocked = true
+			},
+			phase:    PhaseLocked,
+			question: 1,
+		},
+		{
+			name: "reveal right",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.Players["p"].Answers[1] = "1"
+				game.IsLocked, game.IsRevealed = true, true
+			},
+			phase:    PhaseReveal,
+			question: 1,
+			check: func(t *testing.T, view PlayerView) {
+				if !view.Correct {
+					t.Error("right answer not marked correct")
+				}
+			},
+		},
+		{
+			name: "reveal wrong",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.Players["p"].Answers[1] = "2"
+				game.IsLocked, game.IsRevealed = true, true
+			},
+			phase:    PhaseReveal,
+			question: 1,
+			check: func(t *testing.T, view PlayerView) {
+				if view.Correct {
+					t.Error("wrong answer marked correct")
+				}
+			},
+		},
+		{
+			name: "paused",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.IsPaused = true
+			},
+			phase: PhasePaused,
+		},
+		{
+			name: "intermission",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.CurrentQuestion = nil
+				game.IntermissionUntil = time.Now().Add(time.Minute)
+			},
+			phase: PhaseIntermission,
+			check: func(t *testing.T, view PlayerView) {
+				if view.Intermission == nil || view.Intermission.Round.Name != "First" || view.Intermission.Next.Name != "Second" {
+					t.Errorf("intermission = %+v, want from First to Second", view.Intermission)
+				}
+			},
+		},
+		{
+			name: "intermission over",
+			setup: func(game *types.GameState) {
+				open(game, 1)
+				game.CurrentQuestion = nil
+				game.IntermissionUntil = time.Now().Add(-time.Second)
+			},
+			phase: PhaseReady,
+		},
+		{
+			name: "self-paced",
+			setup: func(game *types.GameState) {
+				game.IsActive, game.SelfPaced = true, true
+				game.Players["p"].Answers[1] = "1"
+			},
+			phase:    PhaseQuestion,
+			question: 2,
+			check: func(t *testing.T, view PlayerView) {
+				if view.Number != 2 || view.Round != "Second" {
+					t.Errorf("got question %d in %q, want 2 in Second", view.Number, view.Round)
+				}
+			},
+		},
+		{
+			name: "self-paced done",
+			setup: func(game *types.GameState) {
+				game.IsActive, game.SelfPaced = true, true
+				game.Players["p"].Answers[1] = "1"
+				game.Players["p"].Answers[2] = "2"
+			},
+			phase: PhaseFinished,
+			check: func(t *testing.T, view PlayerView) {
+				if view.Ended {
+					t.Error("game marked ended while still running")
+				}
+			},
+		},
+		{
+			name: "ended",
+			setup: func(game *types.GameState) {
+				game.EndTime = time.Now()
+			},
+			phase: PhaseFinished,
+			check: func(t *testing.T, view PlayerView) {
+				if !view.Ended {
+					t.Error("ended game not marked ended")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := viewGame()
+			tt.setup(game)
+			view := BuildPlayerView(game, "p")
+			if view.Phase != tt.phase {
+				t.Fatalf("phase = %s, want %s", view.Phase, tt.phase)
+			}
+			switch {
+			case tt.question == 0 && view.Question != nil:
+				t.Errorf("shows question %d, want none", view.Question.ID)
+			case tt.question != 0 && (view.Question == nil || view.Question.ID != tt.question):
+				t.Errorf("shows %+v, want question %d", view.Question, tt.question)
+			}
+			if tt.check != nil {
+				tt.check(t, view)
+			}
+		})
+	}
+}
@@ -1,5 +1,30 @@
 package generate
 
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"richetechguy/internal/websocket"
+)
+
+// GenerateMain regenerates build artifacts that are derived from Go code
 func GenerateMain() error {
-	return nil
+	return generateProtocolSchema()
+}
+
+// generateProtocolSchema writes the WebSocket message contract next to the
+// static assets so the front-end can validate against it
+func generateProtocolSchema() error {
+	schema, err := websocket.Schema()
+	if err != nil {
+		return fmt.Errorf("error generating protocol schema: %w", err)
+	}
+	schema = append(schema, '\n')
+
+	path := filepath.Join(".", "static", fmt.Sprintf("protocol.v%d.schema.json", websocket.ProtocolVersion))
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, schema) {
+		return nil
+	}
+	return os.WriteFile(path, schema, 0644)
 }
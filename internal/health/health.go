@@ -0,0 +1,101 @@
+// Package health serves liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Check is one dependency the server needs before it can take traffic
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of every check at one point in time
+type Result struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"` // "ok" or the error message
+	Time   time.Time         `json:"time"`
+}
+
+// Checker runs readiness checks with a shared deadline
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker returns a checker that gives up on all checks after timeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run runs every check and reports whether all of them passed
+func (c *Checker) Run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	result := Result{Ready: true, Checks: make(map[string]string, len(c.checks)), Time: time.Now()}
+	for _, check := range c.checks {
+		if err := check.Run(ctx); err != nil {
+			result.Ready = false
+			result.Checks[check.Name] = err.Error()
+			continue
+		}
+		result.Checks[check.Name] = "ok"
+	}
+	return result
+}
+
+// Err summarises a failed result as an error, or returns nil when ready
+func (r Result) Err() error {
+	if r.Ready {
+		return nil
+	}
+	return &Failure{Checks: r.Checks}
+}
+
+// Failure lists the checks that did not pass
+type Failure struct {
+	Checks map[string]string
+}
+
+func (f *Failure) Error() string {
+	names := make([]string, 0, len(f.Checks))
+	for name, status := range f.Checks {
+		if status != "ok" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	failures := make([]string, len(names))
+	for i, name := range names {
+		failures[i] = name + ": " + f.Checks[name]
+	}
+	return strings.Join(failures, "; ")
+}
+
+// HandleLive answers 200 as long as the process can serve HTTP
+func HandleLive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// HandleReady answers 200 when every check passes and 503 otherwise
+func HandleReady(checker *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := checker.Run(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !result.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(result)
+	}
+}
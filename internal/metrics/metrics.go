@@ -0,0 +1,323 @@
+// Package metrics keeps counters, gauges and histograms in memory and serves
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sample is one labelled value reported by a GaugeFunc
+type Sample struct {
+	Labels []string // values in the order of the gauge's label names
+	Value  float64
+}
+
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds the metrics served by its Handler
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default is the registry the application's metrics are registered in
+var Default = NewRegistry()
+
+func (reg *Registry) register(c collector) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, exists := reg.collectors[c.name()]; exists {
+		panic(fmt.Sprintf("metrics: %s registered twice", c.name()))
+	}
+	reg.collectors[c.name()] = c
+}
+
+// Write writes every metric in name order
+func (reg *Registry) Write(w io.Writer) {
+	reg.mu.RLock()
+	names := make([]string, 0, len(reg.collectors))
+	for name := range reg.collectors {
+		names = append(names, name)
+	}
+	reg.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		reg.mu.RLock()
+		c := reg.collectors[name]
+		reg.mu.RUnlock()
+		c.write(w)
+	}
+}
+
+// Handler serves the registry for a Prometheus scraper
+func (reg *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		reg.Write(w)
+	})
+}
+
+// desc is the name, help text and label names shared by every metric kind
+type desc struct {
+	metricName string
+	help       string
+	kind       string
+	labelNames []string
+}
+
+func (d *desc) name() string { return d.metricName }
+
+func (d *desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, strings.ReplaceAll(d.help, "\n", " "))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, d.kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels renders {name="value",...}, with extra pairs such as le appended
+func (d *desc) labels(values []string, extra ...string) string {
+	if len(d.labelNames) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(d.labelNames)+len(extra)/2)
+	for i, name := range d.labelNames {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(value)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+labelEscaper.Replace(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (d *desc) check(values []string) {
+	if len(values) != len(d.labelNames) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", d.metricName, len(d.labelNames), len(values)))
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// series stores one value per combination of label values
+type series[T any] struct {
+	mu     sync.Mutex
+	values map[string]*T
+	labels map[string][]string
+}
+
+func (s *series[T]) get(values []string, create func() *T) *T {
+	key := strings.Join(values, "\xff")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]*T)
+		s.labels = make(map[string][]string)
+	}
+	v, ok := s.values[key]
+	if !ok {
+		v = create()
+		s.values[key] = v
+		s.labels[key] = append([]string(nil), values...)
+	}
+	return v
+}
+
+// each visits every series in a stable order
+func (s *series[T]) each(fn func(labels []string, v *T)) {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s.mu.Lock()
+		v, labels := s.values[key], s.labels[key]
+		s.mu.Unlock()
+		fn(labels, v)
+	}
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	desc
+	series series[counterValue]
+}
+
+type counterValue struct {
+	mu sync.Mutex
+	v  float64
+}
+
+// NewCounter registers a counter partitioned by labelNames
+func (reg *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{desc: desc{metricName: name, help: help, kind: "counter", labelNames: labelNames}}
+	reg.register(c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.check(labelValues)
+	v := c.series.get(labelValues, func() *counterValue { return &counterValue{} })
+	v.mu.Lock()
+	v.v += delta
+	v.mu.Unlock()
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.header(w)
+	c.series.each(func(labels []string, v *counterValue) {
+		v.mu.Lock()
+		value := v.v
+		v.mu.Unlock()
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labels(labels), formatFloat(value))
+	})
+}
+
+// GaugeFunc is a gauge read from the application each time it is scraped
+type GaugeFunc struct {
+	desc
+	mu     sync.RWMutex
+	source func() []Sample
+}
+
+// NewGaugeFunc registers a gauge. Nothing is reported until SetSource is called.
+func (reg *Registry) NewGaugeFunc(name, help string, labelNames ...string) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{metricName: name, help: help, kind: "gauge", labelNames: labelNames}}
+	reg.register(g)
+	return g
+}
+
+// SetSource sets the function that reports the gauge's current samples
+func (g *GaugeFunc) SetSource(source func() []Sample) {
+	g.mu.Lock()
+	g.source = source
+	g.mu.Unlock()
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.mu.RLock()
+	source := g.source
+	g.mu.RUnlock()
+
+	g.header(w)
+	if source == nil {
+		return
+	}
+	samples := source()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+	for _, sample := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labels(sample.Labels), formatFloat(sample.Value))
+	}
+}
+
+// DefBuckets suit request latencies measured in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	desc
+	buckets []float64
+	series  series[histogramValue]
+}
+
+type histogramValue struct {
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds
+func (reg *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{
+		desc:    desc{metricName: name, help: help, kind: "histogram", labelNames: labelNames},
+		buckets: sorted,
+	}
+	reg.register(h)
+	return h
+}
+
+// Observe records one value
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.check(labelValues)
+	v := h.series.get(labelValues, func() *histogramValue {
+		return &histogramValue{counts: make([]uint64, len(h.buckets))}
+	})
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.sum += value
+	v.count++
+}
+
+// ObserveSince records the seconds elapsed since start
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.header(w)
+	h.series.each(func(labels []string, v *histogramValue) {
+		v.mu.Lock()
+		counts := append([]uint64(nil), v.counts...)
+		sum, count := v.sum, v.count
+		v.mu.Unlock()
+
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(labels, "le", formatFloat(bound)), counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(labels, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labels(labels), formatFloat(sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labels(labels), count)
+	})
+}
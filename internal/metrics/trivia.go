@@ -0,0 +1,31 @@
+package metrics
+
+// Metrics exposed at /metrics. Gauges are filled in by the package that owns
+// the state through SetSource; counters and histograms are updated in place.
+var (
+	ActiveGames = Default.NewGaugeFunc("trivia_active_games",
+		"Games that have started and not yet ended.")
+	GamePlayers = Default.NewGaugeFunc("trivia_game_players",
+		"Players in each game by presence status.", "game_id", "status")
+	AdminConnections = Default.NewGaugeFunc("trivia_admin_connections",
+		"Admin dashboard sockets connected to this instance.")
+	PresenterConnections = Default.NewGaugeFunc("trivia_presenter_connections",
+		"Presenter screens connected to this instance.")
+	SpectatorConnections = Default.NewGaugeFunc("trivia_spectator_connections",
+		"Spectator sockets connected to this instance.")
+
+	Answers = Default.NewCounter("trivia_answers_total",
+		"Answers submitted by players, by result: accepted, rejected or rate_limited.", "result")
+	Broadcasts = Default.NewCounter("trivia_broadcasts_total",
+		"Messages broadcast, by audience.", "audience")
+	DroppedMessages = Default.NewCounter("trivia_dropped_messages_total",
+		"Messages that could not be written to a connection, by audience.", "audience")
+	DBErrors = Default.NewCounter("trivia_db_errors_total",
+		"Failed database operations, by operation.", "op")
+
+	HTTPDuration = Default.NewHistogram("trivia_http_request_duration_seconds",
+		"Latency of HTTP requests. Socket and event stream connections are excluded.",
+		DefBuckets, "method", "route", "status")
+	SaveGameDuration = Default.NewHistogram("trivia_db_save_game_duration_seconds",
+		"Time taken to save a game to the database.", DefBuckets)
+)
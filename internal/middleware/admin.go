@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// IsAdmin reports whether a request carries the configured admin token, as a
+// bearer token or as the password of HTTP basic auth, which is how browsers
+// log in to the dashboard. Nothing is admin when no token is configured.
+func IsAdmin(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, presented, _ = r.BasicAuth()
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(presented)) == 1
+}
+
+// RequireAdmin refuses requests that don't carry the admin token. Browsers are
+// asked to log in; any user name works, with the token as the password.
+// Since browsers send the login with every request, changes must come from
+// this site's own pages, as the admin socket's must.
+func RequireAdmin(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.Error(w, "The admin dashboard is disabled until ADMIN_TOKEN is set", http.StatusForbidden)
+				return
+			}
+			if !safeMethod(r.Method) && !sameOrigin(r) {
+				http.Error(w, "Admin changes must come from the dashboard", http.StatusForbidden)
+				return
+			}
+			if !IsAdmin(r, token) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="trivia admin", charset="UTF-8"`)
+				http.Error(w, "Log in with the admin token as the password", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sameOrigin reports whether a request came from a page on this host, going
+// by its Origin or, failing that, its Referer. Requests with neither, like
+// scripts', are let through to authenticate.
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	u, err := url.Parse(source)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string // the server's ADMIN_TOKEN
+		method  string
+		auth    func(r *http.Request)
+		origin  string
+		referer string
+		want    int
+	}{
+		{name: "disabled", method: "GET", want: http.StatusForbidden},
+		{name: "no login", token: "secret", method: "GET", want: http.StatusUnauthorized},
+		{
+			name: "wrong password", token: "secret", method: "GET",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "guess") },
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "basic auth", token: "secret", method: "GET",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusOK,
+		},
+		{
+			name: "bearer token", token: "secret", method: "GET",
+			auth: func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") },
+			want: http.StatusOK,
+		},
+		{
+			name: "post from the dashboard", token: "secret", method: "POST", origin: "http://trivia.example",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusOK,
+		},
+		{
+			name: "post from another site", token: "secret", method: "POST", origin: "http://evil.example",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusForbidden,
+		},
+		{
+			name: "post referred by another site", token: "secret", method: "POST", referer: "http://evil.example/page",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusForbidden,
+		},
+		{
+			name: "post referred by the dashboard", token: "secret", method: "POST", referer: "http://trivia.example/admin",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusOK,
+		},
+		{
+			name: "delete from an opaque origin", token: "secret", method: "DELETE", origin: "null",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusForbidden,
+		},
+		{
+			name: "post from a script", token: "secret", method: "POST",
+			auth: func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") },
+			want: http.StatusOK,
+		},
+		{
+			name: "get linked from another site", token: "secret", method: "GET", referer: "http://evil.example/page",
+			auth: func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			want: http.StatusOK,
+		},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://trivia.example/admin/games", nil)
+			if tt.auth != nil {
+				tt.auth(r)
+			}
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				r.Header.Set("Referer", tt.referer)
+			}
+			w := httptest.NewRecorder()
+			RequireAdmin(tt.token)(ok).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type contextKey int
+
+const requestKey contextKey = iota
+
+// requestInfo is the per-request state shared by the middleware stack and handlers
+type requestInfo struct {
+	id    string
+	mu    sync.Mutex
+	attrs []any
+}
+
+// NewLogger builds the application logger. format "json" writes JSON lines,
+// anything else writes key=value text. level is one of debug, info, warn or error.
+func NewLogger(w io.Writer, format string, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	if strings.EqualFold(format, "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// RequestID returns the ID assigned to the request that ctx belongs to
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestKey).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// Annotate adds key/value fields, such as the game and player, to every later
+// log line for the request, including its access log entry
+func Annotate(ctx context.Context, args ...any) {
+	info, ok := ctx.Value(requestKey).(*requestInfo)
+	if !ok {
+		return
+	}
+	info.mu.Lock()
+	info.attrs = append(info.attrs, args...)
+	info.mu.Unlock()
+}
+
+// Logger returns the default logger tagged with the request ID and any
+// annotations. Outside of a request it is just the default logger.
+func Logger(ctx context.Context) *slog.Logger {
+	info, ok := ctx.Value(requestKey).(*requestInfo)
+	if !ok {
+		return slog.Default()
+	}
+	return slog.Default().With(info.fields()...)
+}
+
+func (info *requestInfo) fields() []any {
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	fields := make([]any, 0, len(info.attrs)+2)
+	fields = append(fields, "request_id", info.id)
+	return append(fields, info.attrs...)
+}
@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -17,9 +16,11 @@ type CustomContext struct {
 type CustomHandler func(ctx *CustomContext, w http.ResponseWriter, r *http.Request)
 type CustomMiddleware func(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error
 
+// Chain runs per-route middleware and renders template. Request logging is left
+// to AccessLog, which wraps the whole mux.
 func Chain(w http.ResponseWriter, r *http.Request, template templ.Component, middleware ...CustomMiddleware) {
 	customContext := &CustomContext{
-		Context:   context.Background(),
+		Context:   r.Context(),
 		StartTime: time.Now(),
 	}
 	for _, mw := range middleware {
@@ -28,14 +29,13 @@ func Chain(w http.ResponseWriter, r *http.Request, template templ.Component, mid
 			return
 		}
 	}
-	template.Render(customContext, w)
-	Log(customContext, w, r)
+	if err := template.Render(customContext, w); err != nil {
+		Logger(r.Context()).Error("render failed", "path", r.URL.Path, "err", err)
+	}
 }
 
 func Log(ctx *CustomContext, w http.ResponseWriter, r *http.Request) error {
-	elapsedTime := time.Since(ctx.StartTime)
-	formattedTime := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Printf("[%s] [%s] [%s] [%s]\n", formattedTime, r.Method, r.URL.Path, elapsedTime)
+	Logger(ctx).Info("chain", "method", r.Method, "path", r.URL.Path, "elapsed", time.Since(ctx.StartTime))
 	return nil
 }
 
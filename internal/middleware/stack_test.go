@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs points the default logger at a JSON buffer for the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(NewLogger(&buf, "json", "debug"))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+// logLines decodes each JSON log line
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q isn't JSON: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestRequestIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		sent   string
+		reused bool
+	}{
+		{name: "none sent"},
+		{name: "from a proxy", sent: "edge-1234", reused: true},
+		{name: "too long", sent: strings.Repeat("x", 65)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := RequestIDs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestID(r.Context())
+			}))
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.sent != "" {
+				r.Header.Set(RequestIDHeader, tt.sent)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			echoed := w.Header().Get(RequestIDHeader)
+			if seen == "" || seen != echoed {
+				t.Errorf("handler saw %q, response says %q; want the same ID", seen, echoed)
+			}
+			if (seen == tt.sent) != tt.reused {
+				t.Errorf("ID = %q, sent %q, want reused %v", seen, tt.sent, tt.reused)
+			}
+		})
+	}
+}
+
+func TestStack(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Stack(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), mark("first"), mark("second"))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("ran %v, want first then second", order)
+	}
+}
+
+func TestAccessLogAndRecover(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		want      int
+		wantBody  string
+		wantLevel string // of the access log line
+		wantPanic bool
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				Annotate(r.Context(), "game_id", "g1")
+				w.Write([]byte("hello"))
+			},
+			want: http.StatusOK, wantBody: "hello", wantLevel: "INFO",
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				Annotate(r.Context(), "game_id", "g1")
+				http.NotFound(w, r)
+			},
+			want: http.StatusNotFound, wantLevel: "INFO",
+		},
+		{
+			name: "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				Annotate(r.Context(), "game_id", "g1")
+				panic("boom")
+			},
+			want: http.StatusInternalServerError, wantBody: "Internal Server Error", wantLevel: "ERROR", wantPanic: true,
+		},
+		{
+			name: "panic after writing",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				Annotate(r.Context(), "game_id", "g1")
+				w.Write([]byte("partial"))
+				panic("boom")
+			},
+			// The status is already sent, so the access log can't tell
+			want: http.StatusOK, wantBody: "partial", wantLevel: "INFO", wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			h := Stack(tt.handler, RequestIDs, AccessLog, Recover)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/game/g1", nil))
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if body := strings.TrimSpace(w.Body.String()); tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+
+			id := w.Header().Get(RequestIDHeader)
+			var access, panicked map[string]any
+			for _, line := range logLines(t, logs) {
+				if line["request_id"] != id || line["game_id"] != "g1" {
+					t.Errorf("log line %v isn't tagged with request %s and game g1", line, id)
+				}
+				switch line["msg"] {
+				case "request":
+					access = line
+				case "panic serving request":
+					panicked = line
+				}
+			}
+			if access == nil {
+				t.Fatal("no access log line")
+			}
+			if access["status"] != float64(tt.want) || access["level"] != tt.wantLevel || access["path"] != "/game/g1" {
+				t.Errorf("access log = %v, want status %d at %s", access, tt.want, tt.wantLevel)
+			}
+			if (panicked != nil) != tt.wantPanic {
+				t.Errorf("panic logged = %v, want %v", panicked != nil, tt.wantPanic)
+			}
+			if panicked != nil && panicked["panic"] != "boom" {
+				t.Errorf("panic log = %v, want the panic value", panicked)
+			}
+		})
+	}
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "text", "warn")
+	logger.Info("hidden")
+	logger.Warn("shown", "k", "v")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "msg=shown k=v") {
+		t.Errorf("got %q, want only the warning as key=value text", out)
+	}
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Discord posts embeds to a Discord channel webhook
+type Discord struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+const (
+	discordGreen  = 65280    // #00FF00
+	discordOrange = 16744192 // #FF8000
+	discordRed    = 16711680 // #FF0000
+)
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Color       int                `json:"color"`
+	Fields      []discordField     `json:"fields"`
+	Thumbnail   *discordEmbedImage `json:"thumbnail,omitempty"`
+	Timestamp   string             `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type discordEmbedImage struct {
+	URL string `json:"url"`
+}
+
+func (d *Discord) Send(ctx context.Context, event Event) error {
+	color := discordGreen
+	switch {
+	case event.Kind == KindIncident:
+		color = discordRed
+	case !event.Healthy:
+		color = discordOrange
+	}
+
+	fields := []discordField{
+		{Name: "Site URL", Value: event.SiteURL},
+		{Name: "Status", Value: statusText(event.Healthy)},
+	}
+	if event.Detail != "" {
+		fields = append(fields, discordField{Name: "Details", Value: event.Detail})
+	}
+	fields = append(fields, discordField{Name: "Timestamp", Value: event.Time.Format("2006-01-02 15:04:05")})
+
+	embed := discordEmbed{
+		Title:       event.Title(),
+		Description: event.Summary(),
+		Color:       color,
+		Fields:      fields,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339),
+	}
+	if event.SiteURL != "" {
+		embed.Thumbnail = &discordEmbedImage{URL: event.SiteURL + "/favicon.ico"}
+	}
+	return postJSON(ctx, d.Client, d.WebhookURL, discordMessage{Embeds: []discordEmbed{embed}})
+}
+
+func statusText(healthy bool) string {
+	if healthy {
+		return "Ready"
+	}
+	return "Not ready"
+}
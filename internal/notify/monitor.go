@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Config selects the webhooks to notify and how often to check. Leaving both
+// webhook URLs empty disables the notifier.
+type Config struct {
+	SiteName       string
+	SiteURL        string
+	DiscordWebhook string
+	TeamsWebhook   string
+	CheckInterval  time.Duration // how often readiness is checked for incidents
+	ReportInterval time.Duration // how often a status report is posted; zero disables reports
+}
+
+// ConfigFromEnv reads NOTIFY_* variables, falling back to a one minute check
+// and a daily report like the cron scripts used to send
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		SiteName:       os.Getenv("NOTIFY_SITE_NAME"),
+		SiteURL:        os.Getenv("NOTIFY_SITE_URL"),
+		DiscordWebhook: os.Getenv("NOTIFY_DISCORD_WEBHOOK"),
+		TeamsWebhook:   os.Getenv("NOTIFY_TEAMS_WEBHOOK"),
+		CheckInterval:  time.Minute,
+		ReportInterval: 24 * time.Hour,
+	}
+	if cfg.SiteName == "" {
+		cfg.SiteName = "Party Trivia"
+	}
+	if v := os.Getenv("NOTIFY_CHECK_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("NOTIFY_CHECK_INTERVAL: invalid duration %q", v)
+		}
+		cfg.CheckInterval = d
+	}
+	if v := os.Getenv("NOTIFY_REPORT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("NOTIFY_REPORT_INTERVAL: invalid duration %q", v)
+		}
+		cfg.ReportInterval = d
+	}
+	return cfg, nil
+}
+
+// Enabled reports whether any webhook is configured
+func (c Config) Enabled() bool {
+	return c.DiscordWebhook != "" || c.TeamsWebhook != ""
+}
+
+// Senders builds a sender for each configured webhook
+func (c Config) Senders() []Sender {
+	var senders []Sender
+	if c.DiscordWebhook != "" {
+		senders = append(senders, &Discord{WebhookURL: c.DiscordWebhook})
+	}
+	if c.TeamsWebhook != "" {
+		senders = append(senders, &Teams{WebhookURL: c.TeamsWebhook})
+	}
+	return senders
+}
+
+// Monitor checks readiness on a schedule, posting an incident when it starts
+// failing, a recovery when it passes again and a periodic status report
+type Monitor struct {
+	Config  Config
+	Check   func(ctx context.Context) error
+	Senders []Sender
+
+	healthy bool
+}
+
+// NewMonitor returns a monitor that posts to every webhook in cfg
+func NewMonitor(cfg Config, check func(ctx context.Context) error) *Monitor {
+	return &Monitor{Config: cfg, Check: check, Senders: cfg.Senders(), healthy: true}
+}
+
+// Run checks and reports until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	checks := time.NewTicker(m.Config.CheckInterval)
+	defer checks.Stop()
+
+	var reports <-chan time.Time
+	if m.Config.ReportInterval > 0 {
+		ticker := time.NewTicker(m.Config.ReportInterval)
+		defer ticker.Stop()
+		reports = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-checks.C:
+			m.CheckOnce(ctx)
+		case <-reports:
+			m.Report(ctx)
+		}
+	}
+}
+
+// CheckOnce runs the check and notifies if health changed since the last one
+func (m *Monitor) CheckOnce(ctx context.Context) {
+	err := m.Check(ctx)
+	healthy := err == nil
+	if healthy == m.healthy {
+		return
+	}
+	m.healthy = healthy
+
+	event := m.event(KindRecovery, err)
+	if !healthy {
+		event.Kind = KindIncident
+	}
+	m.send(ctx, event)
+}
+
+// Report posts the current status whether or not anything changed
+func (m *Monitor) Report(ctx context.Context) {
+	err := m.Check(ctx)
+	m.healthy = err == nil
+	m.send(ctx, m.event(KindStatus, err))
+}
+
+func (m *Monitor) event(kind Kind, err error) Event {
+	event := Event{
+		Kind:     kind,
+		Healthy:  err == nil,
+		SiteName: m.Config.SiteName,
+		SiteURL:  m.Config.SiteURL,
+		Time:     time.Now(),
+	}
+	if err != nil {
+		event.Detail = err.Error()
+	}
+	return event
+}
+
+func (m *Monitor) send(ctx context.Context, event Event) {
+	for _, sender := range m.Senders {
+		if err := sender.Send(ctx, event); err != nil {
+			slog.Error("sending notification failed", "kind", event.Kind, "sender", fmt.Sprintf("%T", sender), "err", err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package notify posts uptime reports and incidents to chat webhooks. It
+// replaces the old cron scripts that curled the site and called the webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Kind says why a notification is being sent
+type Kind string
+
+const (
+	KindStatus   Kind = "status"   // scheduled report, healthy or not
+	KindIncident Kind = "incident" // checks started failing
+	KindRecovery Kind = "recovery" // checks pass again after an incident
+)
+
+// Event is a single notification about the site's health
+type Event struct {
+	Kind     Kind
+	Healthy  bool
+	SiteName string
+	SiteURL  string
+	Detail   string // the failing checks, empty when healthy
+	Time     time.Time
+}
+
+// Title is the headline shared by every webhook format
+func (e Event) Title() string {
+	switch e.Kind {
+	case KindIncident:
+		return fmt.Sprintf("🚨 %s is down", e.SiteName)
+	case KindRecovery:
+		return fmt.Sprintf("✅ %s has recovered", e.SiteName)
+	}
+	if e.Healthy {
+		return fmt.Sprintf("✅ %s Status Check", e.SiteName)
+	}
+	return fmt.Sprintf("⚠️ %s Status Check", e.SiteName)
+}
+
+// Summary is the one-line description under the title
+func (e Event) Summary() string {
+	switch e.Kind {
+	case KindIncident:
+		return "Readiness checks are failing."
+	case KindRecovery:
+		return "Readiness checks are passing again."
+	}
+	if e.Healthy {
+		return "Status check successful."
+	}
+	return "The status check has detected an issue."
+}
+
+// Sender delivers events to one webhook
+type Sender interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// postJSON sends body to url and treats any non-2xx answer as an error
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("webhook answered %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stub is a webhook that records what it was sent and answers with status
+type stub struct {
+	*httptest.Server
+	status int
+
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+}
+
+// posts returns every body the webhook was sent so far
+func (s *stub) posts() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.bodies...)
+}
+
+func newStub(t *testing.T, status int) *stub {
+	t.Helper()
+	s := &stub{status: status}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		s.mu.Unlock()
+		w.WriteHeader(s.status)
+		if s.status >= 300 {
+			w.Write([]byte("rate limited\n"))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSenders(t *testing.T) {
+	event := Event{
+		Kind:     KindIncident,
+		SiteName: "Trivia",
+		SiteURL:  "https://trivia.example",
+		Detail:   "database: connection refused",
+		Time:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name   string
+		sender func(url string) Sender
+		// title digs the headline out of the posted JSON
+		title func(body map[string]interface{}) string
+	}{
+		{
+			name:   "discord",
+			sender: func(url string) Sender { return &Discord{WebhookURL: url} },
+			title: func(body map[string]interface{}) string {
+				embed := body["embeds"].([]interface{})[0].(map[string]interface{})
+				return embed["title"].(string)
+			},
+		},
+		{
+			name:   "teams",
+			sender: func(url string) Sender { return &Teams{WebhookURL: url} },
+			title: func(body map[string]interface{}) string {
+				return body["summary"].(string)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStub(t, http.StatusNoContent)
+			if err := tt.sender(server.URL).Send(context.Background(), event); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			posts := server.posts()
+			if len(posts) != 1 {
+				t.Fatalf("webhook got %d posts, want 1", len(posts))
+			}
+			if got := tt.title(posts[0]); got != event.Title() {
+				t.Errorf("title = %q, want %q", got, event.Title())
+			}
+			raw, _ := json.Marshal(posts[0])
+			if !strings.Contains(string(raw), event.Detail) {
+				t.Errorf("post %s leaves out the detail %q", raw, event.Detail)
+			}
+		})
+		t.Run(tt.name+" error", func(t *testing.T) {
+			server := newStub(t, http.StatusTooManyRequests)
+			err := tt.sender(server.URL).Send(context.Background(), event)
+			if err == nil || !strings.Contains(err.Error(), "429") || !strings.Contains(err.Error(), "rate limited") {
+				t.Errorf("Send = %v, want an error with the status and body", err)
+			}
+		})
+	}
+}
+
+func TestMonitorCheckOnce(t *testing.T) {
+	server := newStub(t, http.StatusOK)
+	cfg := Config{SiteName: "Trivia", DiscordWebhook: server.URL}
+	var failing error
+	monitor := NewMonitor(cfg, func(context.Context) error { return failing })
+
+	steps := []struct {
+		err  error
+		want string // the title posted, or "" when nothing should be
+	}{
+		{nil, ""},
+		{errors.New("database: down"), "🚨 Trivia is down"},
+		{errors.New("database: still down"), ""},
+		{nil, "✅ Trivia has recovered"},
+		{nil, ""},
+	}
+	for i, step := range steps {
+		failing = step.err
+		before := len(server.posts())
+		monitor.CheckOnce(context.Background())
+		posts := server.posts()[before:]
+		switch {
+		case step.want == "" && len(posts) != 0:
+			t.Errorf("step %d: posted %v, want nothing", i, posts)
+		case step.want != "" && len(posts) != 1:
+			t.Errorf("step %d: posted %d times, want once", i, len(posts))
+		case step.want != "":
+			embed := posts[0]["embeds"].([]interface{})[0].(map[string]interface{})
+			if embed["title"] != step.want {
+				t.Errorf("step %d: title = %q, want %q", i, embed["title"], step.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// Teams posts MessageCards to a Microsoft Teams incoming webhook
+type Teams struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+type teamsCard struct {
+	Type            string         `json:"@type"`
+	Context         string         `json:"@context"`
+	ThemeColor      string         `json:"themeColor"`
+	Summary         string         `json:"summary"`
+	Sections        []teamsSection `json:"sections"`
+	PotentialAction []teamsAction  `json:"potentialAction,omitempty"`
+}
+
+type teamsSection struct {
+	ActivityTitle    string      `json:"activityTitle"`
+	ActivitySubtitle string      `json:"activitySubtitle"`
+	ActivityImage    string      `json:"activityImage,omitempty"`
+	Facts            []teamsFact `json:"facts"`
+	Markdown         bool        `json:"markdown"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	Targets []teamsTarget `json:"targets"`
+}
+
+type teamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+func (t *Teams) Send(ctx context.Context, event Event) error {
+	color := "00FF00"
+	switch {
+	case event.Kind == KindIncident:
+		color = "FF0000"
+	case !event.Healthy:
+		color = "FFA500"
+	}
+
+	facts := []teamsFact{
+		{Name: "Site URL:", Value: event.SiteURL},
+		{Name: "Status:", Value: statusText(event.Healthy)},
+	}
+	if event.Detail != "" {
+		facts = append(facts, teamsFact{Name: "Details:", Value: event.Detail})
+	}
+	facts = append(facts, teamsFact{Name: "Timestamp:", Value: event.Time.Format("2006-01-02 15:04:05")})
+
+	card := teamsCard{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: color,
+		Summary:    event.Title(),
+		Sections: []teamsSection{{
+			ActivityTitle:    event.Title(),
+			ActivitySubtitle: event.Summary(),
+			Facts:            facts,
+			Markdown:         true,
+		}},
+	}
+	if event.SiteURL != "" {
+		card.Sections[0].ActivityImage = event.SiteURL + "/favicon.ico"
+		name := "Visit Site"
+		if !event.Healthy {
+			name = "Check Site"
+		}
+		card.PotentialAction = []teamsAction{{
+			Type:    "OpenUri",
+			Name:    name,
+			Targets: []teamsTarget{{OS: "default", URI: event.SiteURL}},
+		}}
+	}
+	return postJSON(ctx, t.Client, t.WebhookURL, card)
+}
@@ -0,0 +1,201 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepThreshold is how many buckets a Limiter holds before dropping idle ones
+const sweepThreshold = 10000
+
+// Rate is how many events are allowed per Per. It doubles as the burst size.
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+// ParseRate reads a rate written as "count/duration", e.g. "20/1m"
+func ParseRate(s string) (Rate, error) {
+	count, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q: expected count/duration", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: count must be a positive number", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: bad duration", s)
+	}
+	return Rate{Count: n, Per: d}, nil
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Count, r.Per)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a set of token buckets, one per key (an IP, a player, a socket)
+type Limiter struct {
+	rate    Rate
+	buckets map[string]*bucket
+	mu      sync.Mutex
+}
+
+func NewLimiter(rate Rate) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket and reports whether one was available
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, exists := l.buckets[key]
+	if !exists {
+		if len(l.buckets) >= sweepThreshold {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: float64(l.rate.Count), last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill in proportion to the time since the last request
+	refill := now.Sub(b.last).Seconds() * float64(l.rate.Count) / l.rate.Per.Seconds()
+	b.tokens = math.Min(float64(l.rate.Count), b.tokens+refill)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RetryAfter is how long a client that was refused should wait for one token
+func (l *Limiter) RetryAfter() time.Duration {
+	return l.rate.Per / time.Duration(l.rate.Count)
+}
+
+// sweep drops buckets that have had time to refill completely
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > l.rate.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Config holds the abuse limits, read from the environment by ConfigFromEnv
+type Config struct {
+	Joins             Rate  // joins and new connections per client IP
+	Answers           Rate  // answer submissions per player
+	Messages          Rate  // socket frames per connection
+	MaxMessageBytes   int64 // largest socket frame or POST body accepted
+	MaxPlayersPerGame int
+	TrustProxy        bool // take the client IP from X-Forwarded-For
+}
+
+// DefaultConfig allows a whole party behind one router to join at once
+func DefaultConfig() Config {
+	return Config{
+		Joins:             Rate{Count: 30, Per: time.Minute},
+		Answers:           Rate{Count: 5, Per: time.Second},
+		Messages:          Rate{Count: 20, Per: time.Second},
+		MaxMessageBytes:   4096,
+		MaxPlayersPerGame: 100,
+	}
+}
+
+// ConfigFromEnv starts from DefaultConfig and applies any RATE_LIMIT_* overrides
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+
+	rates := map[string]*Rate{
+		"RATE_LIMIT_JOINS":    &cfg.Joins,
+		"RATE_LIMIT_ANSWERS":  &cfg.Answers,
+		"RATE_LIMIT_MESSAGES": &cfg.Messages,
+	}
+	for name, rate := range rates {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := ParseRate(value)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %w", name, err)
+			}
+			*rate = parsed
+		}
+	}
+
+	if value := os.Getenv("MAX_MESSAGE_BYTES"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("MAX_MESSAGE_BYTES: invalid size %q", value)
+		}
+		cfg.MaxMessageBytes = n
+	}
+	if value := os.Getenv("MAX_PLAYERS_PER_GAME"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("MAX_PLAYERS_PER_GAME: invalid count %q", value)
+		}
+		cfg.MaxPlayersPerGame = n
+	}
+	cfg.TrustProxy = os.Getenv("TRUST_PROXY") == "true"
+
+	return cfg, nil
+}
+
+// Limits is the set of limiters shared by the HTTP and socket handlers
+type Limits struct {
+	Config   Config
+	Joins    *Limiter
+	Answers  *Limiter
+	Messages *Limiter
+}
+
+func NewLimits(cfg Config) *Limits {
+	return &Limits{
+		Config:   cfg,
+		Joins:    NewLimiter(cfg.Joins),
+		Answers:  NewLimiter(cfg.Answers),
+		Messages: NewLimiter(cfg.Messages),
+	}
+}
+
+// ClientIP returns the address a request came from
+func (l *Limits) ClientIP(r *http.Request) string {
+	if l.Config.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Reject writes a 429 response telling the client when to try again
+func Reject(w http.ResponseWriter, limiter *Limiter, message string) {
+	retry := int(math.Ceil(limiter.RetryAfter().Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	http.Error(w, message, http.StatusTooManyRequests)
+}
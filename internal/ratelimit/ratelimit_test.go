@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rate
+		wantErr bool
+	}{
+		{in: "20/1m", want: Rate{Count: 20, Per: time.Minute}},
+		{in: "5/1s", want: Rate{Count: 5, Per: time.Second}},
+		{in: "20", wantErr: true},
+		{in: "0/1s", wantErr: true},
+		{in: "-1/1s", wantErr: true},
+		{in: "ten/1s", wantErr: true},
+		{in: "5/soon", wantErr: true},
+		{in: "5/0s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRate(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l := NewLimiter(Rate{Count: 3, Per: 3 * time.Second})
+	for i := range 3 {
+		if !l.Allow("a") {
+			t.Fatalf("request %d of the burst refused", i+1)
+		}
+	}
+	if l.Allow("a") {
+		t.Error("request over the burst allowed")
+	}
+	if !l.Allow("b") {
+		t.Error("another key shares the first one's bucket")
+	}
+
+	// A second later one token has come back, and only one
+	l.buckets["a"].last = l.buckets["a"].last.Add(-time.Second)
+	if !l.Allow("a") {
+		t.Error("refilled token refused")
+	}
+	if l.Allow("a") {
+		t.Error("more than the refill allowed")
+	}
+
+	// Long idle buckets don't refill past the burst
+	l.buckets["a"].last = time.Now().Add(-time.Hour)
+	allowed := 0
+	for l.Allow("a") {
+		allowed++
+	}
+	if allowed != 3 {
+		t.Errorf("after a long wait %d requests allowed, want 3", allowed)
+	}
+
+	if got, want := l.RetryAfter(), time.Second; got != want {
+		t.Errorf("RetryAfter = %s, want %s", got, want)
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	l := NewLimiter(Rate{Count: 1, Per: time.Second})
+	l.Allow("idle")
+	l.Allow("busy")
+	l.buckets["idle"].last = time.Now().Add(-time.Minute)
+	l.sweep(time.Now())
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("idle bucket kept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("busy bucket dropped, which would hand it a fresh burst")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, cfg Config)
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg Config) {
+				if cfg != DefaultConfig() {
+					t.Errorf("got %+v, want the defaults", cfg)
+				}
+			},
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"RATE_LIMIT_ANSWERS":   "2/1s",
+				"MAX_MESSAGE_BYTES":    "1024",
+				"MAX_PLAYERS_PER_GAME": "0",
+				"TRUST_PROXY":          "true",
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Answers != (Rate{Count: 2, Per: time.Second}) || cfg.MaxMessageBytes != 1024 ||
+					cfg.MaxPlayersPerGame != 0 || !cfg.TrustProxy {
+					t.Errorf("got %+v", cfg)
+				}
+				if cfg.Joins != DefaultConfig().Joins {
+					t.Errorf("joins = %v, want the default", cfg.Joins)
+				}
+			},
+		},
+		{name: "bad rate", env: map[string]string{"RATE_LIMIT_JOINS": "lots"}, wantErr: true},
+		{name: "bad size", env: map[string]string{"MAX_MESSAGE_BYTES": "0"}, wantErr: true},
+		{name: "bad player count", env: map[string]string{"MAX_PLAYERS_PER_GAME": "-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"RATE_LIMIT_JOINS", "RATE_LIMIT_ANSWERS", "RATE_LIMIT_MESSAGES",
+				"MAX_MESSAGE_BYTES", "MAX_PLAYERS_PER_GAME", "TRUST_PROXY"} {
+				t.Setenv(name, tt.env[name])
+			}
+			cfg, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "192.0.2.1:5000", want: "192.0.2.1"},
+		{name: "forwarded but untrusted", remoteAddr: "192.0.2.1:5000", forwarded: "198.51.100.7", want: "192.0.2.1"},
+		{name: "behind a proxy", trustProxy: true, remoteAddr: "10.0.0.1:5000", forwarded: "198.51.100.7, 10.0.0.1", want: "198.51.100.7"},
+		{name: "proxy without the header", trustProxy: true, remoteAddr: "10.0.0.1:5000", want: "10.0.0.1"},
+		{name: "IPv6", remoteAddr: "[2001:db8::1]:5000", want: "2001:db8::1"},
+		{name: "no port", remoteAddr: "192.0.2.1", want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimits(Config{TrustProxy: tt.trustProxy})
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := l.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReject(t *testing.T) {
+	w := httptest.NewRecorder()
+	Reject(w, NewLimiter(Rate{Count: 30, Per: time.Minute}), "Too many joins")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+}
@@ -0,0 +1,135 @@
+package template
+
+import (
+	"context"
+	"richetechguy/internal/game"
+	"richetechguy/internal/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// render returns a component's HTML
+func render(t *testing.T, c templ.Component) string {
+	t.Helper()
+	var b strings.Builder
+	if err := c.Render(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestPlayerView(t *testing.T) {
+	single := &types.Question{ID: 7, Type: types.SingleChoice, Text: "Capital of France?", Options: []string{"Lyon", "Paris"}, Correct: "2"}
+	multiple := &types.Question{ID: 8, Type: types.MultipleChoice, Text: "Pick the primes", Options: []string{"2", "4", "5"}, Correct: "1,3"}
+	board := []game.Ranking{
+		{Rank: 1, PlayerID: "p", Name: "Pat", Score: 20, Delta: 2},
+		{Rank: 2, PlayerID: "q", Name: "Quinn", Score: 10, Delta: -1},
+	}
+	tests := []struct {
+		name    string
+		view    game.PlayerView
+		oob     bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "lobby",
+			view: game.PlayerView{Phase: game.PhaseLobby, PlayerID: "p", Players: []*types.Player{
+				{ID: "p", Name: "Pat"},
+				{ID: "q", Name: "<b>Quinn</b>"},
+			}},
+			want:    []string{"Waiting for the game to start", `<div class="p-2 border-b font-semibold">Pat</div>`, "&lt;b&gt;Quinn&lt;/b&gt;"},
+			notWant: []string{"<b>Quinn</b>", "hx-swap-oob"},
+		},
+		{
+			name: "pushed",
+			view: game.PlayerView{Phase: game.PhaseReady},
+			oob:  true,
+			want: []string{`id="player-view"`, `hx-swap-oob="true"`, "Get ready!"},
+		},
+		{
+			name: "single choice",
+			view: game.PlayerView{Phase: game.PhaseQuestion, GameID: "g1", PlayerID: "p", Question: single, Number: 3, Total: 10,
+				Round: "Geography", Deadline: time.Now().Add(20 * time.Second)},
+			want: []string{
+				"Geography · Question 3 of 10", "Capital of France?", "data-countdown=",
+				`name="gameID" value="g1"`, `name="playerID" value="p"`, `name="questionID" value="7"`,
+				`type="radio" name="answer" value="1"`, `value="2" class="mr-2" required`, "Paris",
+			},
+			notWant: []string{"checkbox", "Correct", "Lyon, Paris"},
+		},
+		{
+			name:    "multiple choice without a countdown",
+			view:    game.PlayerView{Phase: game.PhaseQuestion, Question: multiple, Number: 1, Total: 1},
+			want:    []string{"Question 1 of 1", `type="checkbox" name="answer" value="3"`},
+			notWant: []string{"required", "data-countdown", " · "},
+		},
+		{
+			name: "answer locked in",
+			view: game.PlayerView{Phase: game.PhaseLocked, Question: single, Number: 1, Total: 2, Answered: true, Answer: "1"},
+			want: []string{"Your answer is locked in", `<span class="font-semibold">Lyon</span>`},
+		},
+		{
+			name: "closed without an answer",
+			view: game.PlayerView{Phase: game.PhaseLocked, Question: single, Number: 1, Total: 2},
+			want: []string{"Answers are closed for this question."},
+		},
+		{
+			name: "revealed right",
+			view: game.PlayerView{Phase: game.PhaseReveal, PlayerID: "p", Question: single, Answered: true, Answer: "2", Correct: true,
+				Leaderboard: board, Me: &board[0]},
+			want: []string{"border-green-500", "You got it!", "Correct answer: <span class=\"font-semibold\">Paris</span>",
+				"You&#39;re 1st, up 2", "bg-blue-100 font-semibold", "1. Pat", "▲2", "2. Quinn", "▼1"},
+			notWant: []string{"border-red-500"},
+		},
+		{
+			name: "revealed wrong",
+			view: game.PlayerView{Phase: game.PhaseReveal, Question: single, Answered: true, Answer: "1"},
+			want: []string{"border-red-500", "Not quite, you answered Lyon"},
+		},
+		{
+			name: "revealed unanswered",
+			view: game.PlayerView{Phase: game.PhaseReveal, Question: single},
+			want: []string{"You didn't answer this one."},
+		},
+		{
+			name: "intermission",
+			view: game.PlayerView{Phase: game.PhaseIntermission, PlayerID: "q", Intermission: &game.Intermission{
+				Round:     types.Round{Name: "Warm-up"},
+				Next:      &types.Round{Name: "Speed round", Points: 20, Scoring: types.ScoringSpeed},
+				Until:     time.Now().Add(time.Minute),
+				Standings: board,
+			}},
+			want: []string{"Warm-up complete", "Up next: Speed round", "Starting in about", "Round standings", "2. Quinn"},
+		},
+		{
+			name: "done before the game ends",
+			view: game.PlayerView{Phase: game.PhaseFinished, Leaderboard: board},
+			want: []string{"All done!", "Leaderboard"},
+		},
+		{
+			name:    "game over",
+			view:    game.PlayerView{Phase: game.PhaseFinished, Ended: true, Leaderboard: board[:1], Around: board[1:]},
+			want:    []string{"Game over", "…", "2. Quinn"},
+			notWant: []string{"All done!"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := render(t, PlayerView(tt.view, tt.oob))
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("missing %q in\n%s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("unexpected %q in\n%s", notWant, html)
+				}
+			}
+		})
+	}
+}
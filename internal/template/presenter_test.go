@@ -0,0 +1,94 @@
+package template
+
+import (
+	"richetechguy/internal/game"
+	"richetechguy/internal/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresenterView(t *testing.T) {
+	question := &types.Question{ID: 1, Type: types.SingleChoice, Text: "Largest planet?", Options: []string{"Mars", "Jupiter", "Venus", "Earth"},
+		Correct: "2", Media: "https://example.com/planets.png"}
+	board := []game.Ranking{
+		{Rank: 1, PlayerID: "p", Name: "Pat", Score: 30, Delta: 1},
+		{Rank: 2, PlayerID: "q", Name: "Quinn", Score: 20, Delta: -1},
+	}
+	tests := []struct {
+		name    string
+		view    game.PresenterView
+		oob     bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "lobby",
+			view: game.PresenterView{Phase: game.PhaseLobby, Players: 1, Roster: []*types.Player{{ID: "p", Name: "<i>Pat</i>"}}},
+			want: []string{"Join on your phone!", "1 player waiting", "&lt;i&gt;Pat&lt;/i&gt;"},
+		},
+		{
+			name:    "ready",
+			view:    game.PresenterView{Phase: game.PhaseReady, Players: 3},
+			oob:     true,
+			want:    []string{`id="presenter-view" hx-swap-oob="true"`, "Get ready!", "3 players playing"},
+			notWant: []string{"Answer on your phones!"},
+		},
+		{
+			name: "self-paced",
+			view: game.PresenterView{Phase: game.PhaseReady, SelfPaced: true, Leaderboard: board},
+			want: []string{"Answer on your phones!", `data-flip-key="p"`, "1. Pat"},
+		},
+		{
+			name: "question",
+			view: game.PresenterView{Phase: game.PhaseQuestion, Question: question, Number: 2, Total: 5, Round: "Space",
+				Deadline: time.Now().Add(30 * time.Second), Answered: 1, Players: 4},
+			want: []string{"Space · Question 2 of 5", "Largest planet?", "data-countdown=", `src="https://example.com/planets.png"`,
+				"A. Mars", "D. Earth", "1 of 4 answered"},
+			notWant: []string{"Answers closed", "bg-green-600"},
+		},
+		{
+			name:    "locked",
+			view:    game.PresenterView{Phase: game.PhaseLocked, Question: question, Number: 2, Total: 5},
+			want:    []string{"Answers closed"},
+			notWant: []string{"data-countdown"},
+		},
+		{
+			name: "reveal",
+			view: game.PresenterView{Phase: game.PhaseReveal, Question: question, Number: 2, Total: 5, Answered: 4, Players: 4,
+				Counts: []int{1, 2, 1, 0}, Correct: []bool{false, true, false, false}, Leaderboard: board},
+			want: []string{"bg-green-600 ring-4", "width: 50%", "width: 25%", "width: 0%", "B. Jupiter",
+				"2. Quinn", "▲1", "▼1", "bg-yellow-500"},
+		},
+		{
+			name: "intermission",
+			view: game.PresenterView{Phase: game.PhaseIntermission, Intermission: &game.Intermission{
+				Round:     types.Round{Name: "Warm-up"},
+				Next:      &types.Round{Name: "Finale"},
+				Until:     time.Now().Add(time.Minute),
+				Standings: board,
+			}},
+			want: []string{"Warm-up complete", "Up next: Finale", "data-countdown=", "1. Pat"},
+		},
+		{
+			name: "finished",
+			view: game.PresenterView{Phase: game.PhaseFinished, Leaderboard: board},
+			want: []string{"Final standings", `data-flip-key="q"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := render(t, PresenterView(tt.view, tt.oob))
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("missing %q in\n%s", want, html)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("unexpected %q in\n%s", notWant, html)
+				}
+			}
+		})
+	}
+}
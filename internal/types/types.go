@@ -2,12 +2,13 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
 type QuestionType string
@@ -39,6 +40,26 @@ type Question struct {
 	Options []string     `json:"options"`
 	Type    QuestionType `json:"type"`
 	Correct string       `json:"correct"`
+	// TimeLimit is how many seconds players get to answer; zero means no countdown
+	TimeLimit int `json:"timeLimit,omitempty"`
+	// Media is the URL of an image shown with the question
+	Media string `json:"media,omitempty"`
+	// Accepted are other answers the host ruled right after a dispute
+	Accepted []string `json:"accepted,omitempty"`
+}
+
+// Accepts reports whether answer is right for q: the correct answer, in any
+// order for multiple choice, or one the host accepted
+func (q *Question) Accepts(answer string) bool {
+	if q.ValidateAnswer(answer) || answer == q.Correct {
+		return true
+	}
+	for _, accepted := range q.Accepted {
+		if compareAnswerSets(strings.Split(accepted, ","), strings.Split(answer, ",")) {
+			return true
+		}
+	}
+	return false
 }
 
 // ValidateType ensures the question type is valid
@@ -63,16 +84,183 @@ func (qt *QuestionType) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Close codes passed to Transport.Close, in the WebSocket application range
+const (
+	CloseNormal   = 1000
+	CloseKicked   = 4000
+	CloseReplaced = 4001
+)
+
+// PresenceStatus describes whether a player is still reachable
+type PresenceStatus string
+
+const (
+	PresenceConnected    PresenceStatus = "connected"
+	PresenceAway         PresenceStatus = "away"
+	PresenceDisconnected PresenceStatus = "disconnected"
+)
+
+// IsValid checks if the presence status is valid
+func (ps PresenceStatus) IsValid() bool {
+	switch ps {
+	case PresenceConnected, PresenceAway, PresenceDisconnected:
+		return true
+	default:
+		return false
+	}
+}
+
+// String implements the Stringer interface
+func (ps PresenceStatus) String() string {
+	return string(ps)
+}
+
 // Player represents a game participant
 type Player struct {
-	ID      string          `json:"id"`
-	Name    string          `json:"name"`
-	Score   int             `json:"score"`
-	Answers map[int]string  `json:"answers"` // maps question ID to answer
-	WSConn  *websocket.Conn // Add this field
-	GameID  string
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Score   int            `json:"score"`
+	Answers map[int]string `json:"answers"` // maps question ID to answer
+	// ResponseTimes maps question ID to milliseconds from the question opening to the answer
+	ResponseTimes map[int]int64 `json:"responseTimes,omitempty"`
+	// Adjustments are the host's manual score changes, kept apart so
+	// recomputing the score from answers doesn't lose them
+	Adjustments []ScoreAdjustment `json:"adjustments,omitempty"`
+	// Rulings maps question ID to the host's call on the player's answer,
+	// which beats whatever the answer was
+	Rulings map[int]bool `json:"rulings,omitempty"`
+	// Anonymous players were given a generated name by a socket that joined
+	// without one. They are left out of the lists other players see.
+	Anonymous bool      `json:"anonymous,omitempty"`
+	IP        string    `json:"-"` // where the player joined from, for bans
+	Conn      Transport `json:"-"`
+	GameID    string
+	Status    PresenceStatus `json:"status"`
+	LastSeen  time.Time      `json:"lastSeen"`
+
+	// connMu serialises writes to Conn and guards the presence fields
+	connMu sync.Mutex
+}
+
+// ScoreAdjustment is points the host awarded (or deducted, when negative) by hand
+type ScoreAdjustment struct {
+	Delta  int       `json:"delta"`
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// Equal reports whether two adjustments are the same change, e.g. one made
+// here and its copy replicated back from another instance
+func (a ScoreAdjustment) Equal(b ScoreAdjustment) bool {
+	return a.Delta == b.Delta && a.Reason == b.Reason && a.At.Equal(b.At)
 }
 
+// Ban keeps a removed player out of a game for the rest of its life, both
+// by their player ID and by the address they joined from
+type Ban struct {
+	PlayerID string    `json:"playerId"`
+	Name     string    `json:"name"`
+	IP       string    `json:"ip,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Theme is how a game is branded on the join, lobby, player and presenter
+// pages. Empty fields fall back to the defaults.
+type Theme struct {
+	Title          string `json:"title,omitempty"`
+	Subtitle       string `json:"subtitle,omitempty"`
+	LogoURL        string `json:"logoUrl,omitempty"`
+	BackgroundURL  string `json:"backgroundUrl,omitempty"`
+	PrimaryColor   string `json:"primaryColor,omitempty"`   // buttons and highlights, as #rrggbb
+	SecondaryColor string `json:"secondaryColor,omitempty"` // text on the primary color
+	LinkURL        string `json:"linkUrl,omitempty"`        // an optional external link, like a sponsor
+	LinkText       string `json:"linkText,omitempty"`
+}
+
+// ScoringMode is how a round turns answers into points
+type ScoringMode string
+
+const (
+	ScoringStandard ScoringMode = "standard" // right answers score the round's points
+	ScoringSpeed    ScoringMode = "speed"    // faster right answers score more, down to half at the buzzer
+	ScoringPenalty  ScoringMode = "penalty"  // wrong answers lose the round's points
+)
+
+// IsValid checks if the scoring mode is valid. Empty means standard.
+func (m ScoringMode) IsValid() bool {
+	switch m {
+	case "", ScoringStandard, ScoringSpeed, ScoringPenalty:
+		return true
+	default:
+		return false
+	}
+}
+
+// Round is a named part of a game, like "Round 1: 80s Movies" or a lightning
+// round, with its own questions, points, timer and scoring
+type Round struct {
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+	// Points is what a right answer scores; zero means PointsPerAnswer
+	Points int `json:"points,omitempty"`
+	// TimeLimit is the countdown in seconds for questions that don't set their own
+	TimeLimit int         `json:"timeLimit,omitempty"`
+	Scoring   ScoringMode `json:"scoring,omitempty"`
+	// Intermission is how many seconds of break follow the round, with its standings
+	Intermission int `json:"intermission,omitempty"`
+	// QuestionIDs are the round's questions in the game's deck, in order
+	QuestionIDs []int `json:"questionIds"`
+}
+
+// PointsPerAnswer is what a right answer in the round scores
+func (r Round) PointsPerAnswer() int {
+	if r.Points > 0 {
+		return r.Points
+	}
+	return PointsPerAnswer
+}
+
+// IsLast reports whether a question is the round's last
+func (r Round) IsLast(questionID int) bool {
+	return len(r.QuestionIDs) > 0 && r.QuestionIDs[len(r.QuestionIDs)-1] == questionID
+}
+
+// AuditEntry records a host action that changed a running game
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	GameID    string    `json:"gameId"`
+	Action    string    `json:"action"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ErrGameFull is returned when a game has reached its player cap
+var ErrGameFull = errors.New("game is full")
+
+// ErrNameTaken is returned when another player took a name between it being
+// checked and used
+var ErrNameTaken = errors.New("that name was just taken")
+
+// ErrBanned is returned when a banned player or address tries to join a game
+var ErrBanned = errors.New("you have been banned from this game")
+
+// ErrGameStarted is returned when a game that doesn't take late joins has started
+var ErrGameStarted = errors.New("this game has already started")
+
+// ErrGameEnded is returned when someone tries to join a game that is over
+var ErrGameEnded = errors.New("this game has ended")
+
+// LateJoinPolicy decides whether players can join a game that has started,
+// and what score they start with
+type LateJoinPolicy string
+
+const (
+	LateJoinDisallow LateJoinPolicy = "disallow" // refuse joins once the game starts
+	LateJoinZero     LateJoinPolicy = "zero"     // join with no points
+	LateJoinMedian   LateJoinPolicy = "median"   // join with the median of everyone's score
+)
+
 // GameState represents the current state of a trivia game
 type GameState struct {
 	ID              string
@@ -80,11 +268,47 @@ type GameState struct {
 	CurrentQuestion *Question
 	Questions       []Question
 	IsActive        bool
-	Round           int
-	Name            string
-	StartTime       time.Time
-	EndTime         time.Time
-	Mu              sync.RWMutex
+	IsPaused        bool
+	IsLocked        bool // no more answers accepted for CurrentQuestion
+	IsRevealed      bool // the correct answer to CurrentQuestion has been shown
+	// SelfPaced lets each player work through the whole deck at their own pace
+	// instead of the host opening questions one at a time
+	SelfPaced bool
+	// Round is how many questions have been opened, so the current question
+	// is Questions[Round-1]. Rounds groups those questions into named rounds.
+	Round int
+	// Rounds split Questions into named rounds with their own points, timer
+	// and scoring. A game without rounds scores every question the standard way.
+	Rounds []Round
+	// IntermissionUntil is when the break after a round ends; zero outside one
+	IntermissionUntil time.Time
+	Name              string
+	StartTime         time.Time
+	EndTime           time.Time
+	// QuestionOpenedAt is when CurrentQuestion was opened, for response times.
+	// Resuming a paused game moves it on by the pause, so pauses don't count.
+	QuestionOpenedAt time.Time
+	// PausedAt is when the game was paused; zero while it is running
+	PausedAt time.Time
+	// ExtraTime is how many seconds the host added to CurrentQuestion's time limit
+	ExtraTime int
+	// Voided holds the IDs of questions that score nothing for anyone
+	Voided map[int]bool
+	// Bans are the players the host removed for good
+	Bans []Ban
+	// LateJoin is what happens when someone joins after the game started;
+	// empty means LateJoinDisallow
+	LateJoin LateJoinPolicy
+	// Spectators is how many sockets are following the game without playing.
+	// It isn't saved, since they reconnect after a restart.
+	Spectators int
+	// PresenterToken lets a big screen follow the game without admin credentials
+	PresenterToken string
+	Theme          Theme
+	// RanksBefore is each player's rank when CurrentQuestion opened, so the
+	// leaderboard can show who moved
+	RanksBefore map[string]int
+	Mu          sync.RWMutex
 }
 
 // func (gs *GameState) SetQuestions(questions []Question) {
@@ -119,10 +343,30 @@ func (gs *GameState) EndGame() {
 	gs.calculateFinalScores()
 }
 
+// NextQuestion opens the next question. When the current question ends a
+// round that has an intermission, it starts the intermission instead and
+// returns nil; the next call opens the following round.
 func (gs *GameState) NextQuestion() (*Question, error) {
 	gs.Mu.Lock()
 	defer gs.Mu.Unlock()
+	return gs.nextQuestion()
+}
+
+// EndIntermission opens the round after an intermission once the break is
+// over. It returns an error when the game isn't in an intermission that has
+// run out, or is paused.
+func (gs *GameState) EndIntermission(now time.Time) (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
 
+	if gs.IsPaused || gs.IntermissionUntil.IsZero() || now.Before(gs.IntermissionUntil) {
+		return nil, fmt.Errorf("no intermission has ended")
+	}
+	return gs.nextQuestion()
+}
+
+// nextQuestion is NextQuestion for callers that hold gs.Mu
+func (gs *GameState) nextQuestion() (*Question, error) {
 	if !gs.IsActive {
 		return nil, fmt.Errorf("game is not active")
 	}
@@ -131,11 +375,339 @@ func (gs *GameState) NextQuestion() (*Question, error) {
 		return nil, fmt.Errorf("no more questions")
 	}
 
+	if gs.IntermissionUntil.IsZero() && gs.Round > 0 {
+		last := gs.Questions[gs.Round-1].ID
+		if r := gs.RoundOf(last); r >= 0 && gs.Rounds[r].Intermission > 0 && gs.Rounds[r].IsLast(last) {
+			gs.IntermissionUntil = time.Now().Add(time.Duration(gs.Rounds[r].Intermission) * time.Second)
+			gs.CurrentQuestion = nil
+			gs.IsLocked = false
+			gs.IsRevealed = false
+			gs.ExtraTime = 0
+			return nil, nil
+		}
+	}
+	gs.IntermissionUntil = time.Time{}
+
 	gs.Round++
 	gs.CurrentQuestion = &gs.Questions[gs.Round-1]
+	gs.QuestionOpenedAt = time.Now()
+	gs.ExtraTime = 0
+	gs.IsLocked = false
+	gs.IsRevealed = false
+	return gs.CurrentQuestion, nil
+}
+
+// Pause stops the game from accepting answers and freezes the countdown
+// until Resume is called
+func (gs *GameState) Pause() error {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if !gs.IsActive {
+		return fmt.Errorf("game is not active")
+	}
+	if gs.IsPaused {
+		return fmt.Errorf("game is already paused")
+	}
+	gs.IsPaused = true
+	gs.PausedAt = time.Now()
+	return nil
+}
+
+// Resume restarts a paused game, giving the current question back the time it lost
+func (gs *GameState) Resume() error {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if !gs.IsPaused {
+		return fmt.Errorf("game is not paused")
+	}
+	if !gs.QuestionOpenedAt.IsZero() && !gs.PausedAt.IsZero() {
+		gs.QuestionOpenedAt = gs.QuestionOpenedAt.Add(time.Since(gs.PausedAt))
+	}
+	gs.IsPaused = false
+	gs.PausedAt = time.Time{}
+	return nil
+}
+
+// AnswerGrace is how long after Deadline answers are still taken, to make up
+// for the time they spend on the network
+const AnswerGrace = time.Second
+
+// Deadline is when the countdown on the current question runs out. It is
+// zero when there is no open question or it has no time limit, and stays put
+// while the game is paused. Callers hold Mu.
+func (gs *GameState) Deadline() time.Time {
+	if gs.CurrentQuestion == nil || gs.CurrentQuestion.TimeLimit <= 0 {
+		return time.Time{}
+	}
+	deadline := gs.QuestionOpenedAt.Add(time.Duration(gs.CurrentQuestion.TimeLimit+gs.ExtraTime) * time.Second)
+	if !gs.PausedAt.IsZero() {
+		deadline = deadline.Add(time.Since(gs.PausedAt))
+	}
+	return deadline
+}
+
+// LockExpired locks the current question once its countdown and AnswerGrace
+// have run out by now, and returns it; it returns nil when the question is
+// still open, has no countdown, or the game is paused
+func (gs *GameState) LockExpired(now time.Time) *Question {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if !gs.IsActive || gs.IsPaused || gs.IsLocked || gs.IsRevealed {
+		return nil
+	}
+	deadline := gs.Deadline()
+	if deadline.IsZero() || now.Before(deadline.Add(AnswerGrace)) {
+		return nil
+	}
+	gs.IsLocked = true
+	return gs.CurrentQuestion
+}
+
+// ReopenQuestion takes answers to the current question again after it was
+// locked or revealed, with a fresh countdown
+func (gs *GameState) ReopenQuestion() (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if gs.CurrentQuestion == nil {
+		return nil, fmt.Errorf("no active question")
+	}
+	if !gs.IsLocked && !gs.IsRevealed {
+		return nil, fmt.Errorf("question is still open")
+	}
+	gs.IsLocked = false
+	gs.IsRevealed = false
+	gs.QuestionOpenedAt = time.Now()
+	gs.ExtraTime = 0
+	if gs.IsPaused {
+		gs.PausedAt = gs.QuestionOpenedAt
+	}
 	return gs.CurrentQuestion, nil
 }
 
+// ExtendTimer adds seconds to the current question's countdown
+func (gs *GameState) ExtendTimer(seconds int) (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if gs.CurrentQuestion == nil {
+		return nil, fmt.Errorf("no active question")
+	}
+	if gs.IsLocked {
+		return nil, fmt.Errorf("question is locked")
+	}
+	if gs.CurrentQuestion.TimeLimit <= 0 {
+		return nil, fmt.Errorf("question has no time limit")
+	}
+	if seconds <= 0 {
+		return nil, fmt.Errorf("extension must be a positive number of seconds")
+	}
+	gs.ExtraTime += seconds
+	return gs.CurrentQuestion, nil
+}
+
+// VoidQuestion stops a question counting for anyone and takes back the
+// points it gave. Answers are kept, so the results still show them.
+func (gs *GameState) VoidQuestion(questionID int) (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	question := gs.question(questionID)
+	if question == nil {
+		return nil, fmt.Errorf("unknown question %d", questionID)
+	}
+	if gs.Voided[questionID] {
+		return nil, fmt.Errorf("question %d is already void", questionID)
+	}
+	if gs.Voided == nil {
+		gs.Voided = make(map[int]bool)
+	}
+	gs.Voided[questionID] = true
+	gs.recomputeScores()
+	return question, nil
+}
+
+// RecomputeScores rebuilds every player's score from their answers
+func (gs *GameState) RecomputeScores() {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+	gs.recomputeScores()
+}
+
+// LockQuestion closes the current question to further answers
+func (gs *GameState) LockQuestion() (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if gs.CurrentQuestion == nil {
+		return nil, fmt.Errorf("no active question")
+	}
+	gs.IsLocked = true
+	return gs.CurrentQuestion, nil
+}
+
+// RevealAnswer locks the current question and returns it so its answer can be shown
+func (gs *GameState) RevealAnswer() (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if gs.CurrentQuestion == nil {
+		return nil, fmt.Errorf("no active question")
+	}
+	gs.IsLocked = true
+	gs.IsRevealed = true
+	return gs.CurrentQuestion, nil
+}
+
+// AddPlayer puts a player into the game, refusing once maxPlayers have joined
+// or when someone else's name has the same nameKey. A maxPlayers of zero
+// means no limit.
+func (gs *GameState) AddPlayer(player *Player, maxPlayers int, nameKey func(string) string) error {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if maxPlayers > 0 && len(gs.Players) >= maxPlayers {
+		return ErrGameFull
+	}
+	if gs.nameTaken(player.Name, player.ID, nameKey) {
+		return ErrNameTaken
+	}
+	gs.Players[player.ID] = player
+	return nil
+}
+
+// nameTaken reports whether a player other than playerID has a name with the
+// same key. Callers hold gs.Mu.
+func (gs *GameState) nameTaken(name, playerID string, nameKey func(string) string) bool {
+	key := nameKey(name)
+	for id, player := range gs.Players {
+		if id != playerID && nameKey(player.Name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RemovePlayer takes a player out of the game and returns them
+func (gs *GameState) RemovePlayer(playerID string) (*Player, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	player, exists := gs.Players[playerID]
+	if !exists {
+		return nil, fmt.Errorf("player not found")
+	}
+	delete(gs.Players, playerID)
+	return player, nil
+}
+
+// BanPlayer removes a player and keeps their ID and address out of the game
+func (gs *GameState) BanPlayer(playerID, reason string) (*Player, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	player, exists := gs.Players[playerID]
+	if !exists {
+		return nil, fmt.Errorf("player not found")
+	}
+	delete(gs.Players, playerID)
+	gs.Bans = append(gs.Bans, Ban{PlayerID: player.ID, Name: player.Name, IP: player.IP, Reason: reason, At: time.Now()})
+	return player, nil
+}
+
+// IsBanned reports whether a player ID or address was banned from the game.
+// Either may be empty.
+func (gs *GameState) IsBanned(playerID, ip string) bool {
+	gs.Mu.RLock()
+	defer gs.Mu.RUnlock()
+
+	for _, ban := range gs.Bans {
+		if (playerID != "" && ban.PlayerID == playerID) || (ip != "" && ban.IP == ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenamePlayer changes a player's name and returns the old one, refusing a
+// name with the same nameKey as someone else's
+func (gs *GameState) RenamePlayer(playerID, name string, nameKey func(string) string) (string, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	player, exists := gs.Players[playerID]
+	if !exists {
+		return "", fmt.Errorf("player not found")
+	}
+	if gs.nameTaken(name, playerID, nameKey) {
+		return "", ErrNameTaken
+	}
+	old := player.Name
+	player.Name = name
+	player.Anonymous = false
+	return old, nil
+}
+
+// AdjustScore adds delta (which may be negative) to a player's score and returns the new score
+func (gs *GameState) AdjustScore(playerID string, delta int, reason string) (int, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	player, exists := gs.Players[playerID]
+	if !exists {
+		return 0, fmt.Errorf("player not found")
+	}
+	player.Adjustments = append(player.Adjustments, ScoreAdjustment{Delta: delta, Reason: reason, At: time.Now()})
+	gs.scorePlayer(player)
+	return player.Score, nil
+}
+
+// AcceptAnswer rules another answer to a question right and rescores everyone who gave it
+func (gs *GameState) AcceptAnswer(questionID int, answer string) (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	question := gs.question(questionID)
+	if question == nil {
+		return nil, fmt.Errorf("unknown question %d", questionID)
+	}
+	if question.Accepts(answer) {
+		return nil, fmt.Errorf("that answer is already accepted")
+	}
+	question.Accepted = append(question.Accepted, answer)
+	if gs.CurrentQuestion != nil && gs.CurrentQuestion.ID == questionID && gs.CurrentQuestion != question {
+		// A replicated game holds its own copy of the current question
+		gs.CurrentQuestion.Accepted = question.Accepted
+	}
+	gs.recomputeScores()
+	return question, nil
+}
+
+// RuleAnswer marks one player's answer to a question right or wrong,
+// whatever they answered, or even if they never got to answer
+func (gs *GameState) RuleAnswer(playerID string, questionID int, correct bool) (*Question, error) {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	player, exists := gs.Players[playerID]
+	if !exists {
+		return nil, fmt.Errorf("player not found")
+	}
+	question := gs.question(questionID)
+	if question == nil {
+		return nil, fmt.Errorf("unknown question %d", questionID)
+	}
+	if player.Rulings == nil {
+		player.Rulings = make(map[int]bool)
+	}
+	player.Rulings[questionID] = correct
+	gs.scorePlayer(player)
+	return question, nil
+}
+
 func (gs *GameState) SubmitAnswer(playerID string, answer string) error {
 	gs.Mu.Lock()
 	defer gs.Mu.Unlock()
@@ -145,65 +717,423 @@ func (gs *GameState) SubmitAnswer(playerID string, answer string) error {
 		return fmt.Errorf("player not found")
 	}
 
-	if gs.CurrentQuestion == nil {
+	if gs.SelfPaced || gs.CurrentQuestion == nil {
 		return fmt.Errorf("no active question")
 	}
+	if err := gs.takesAnswer(player, gs.CurrentQuestion.ID); err != nil {
+		return err
+	}
 
 	player.Answers[gs.CurrentQuestion.ID] = answer
+	gs.timeAnswer(player, gs.CurrentQuestion.ID)
+	gs.scorePlayer(player)
+	return nil
+}
+
+// RecordAnswer stores and scores a player's first answer to a specific
+// question, timing it when that question is the one currently open
+func (gs *GameState) RecordAnswer(player *Player, questionID int, answer string) error {
+	gs.Mu.Lock()
+	defer gs.Mu.Unlock()
+
+	if err := gs.takesAnswer(player, questionID); err != nil {
+		return err
+	}
+
+	player.SubmitAnswer(questionID, answer)
+	gs.timeAnswer(player, questionID)
+	gs.scorePlayer(player)
+	return nil
+}
+
+// takesAnswer reports why a player can't answer a question right now, or
+// returns nil when they can. In self-paced games that is their next
+// unanswered question; otherwise only the open current question takes
+// answers, until its countdown runs out. Callers hold gs.Mu.
+func (gs *GameState) takesAnswer(player *Player, questionID int) error {
+	if !gs.IsActive {
+		return fmt.Errorf("game is not active")
+	}
+	if gs.IsPaused {
+		return fmt.Errorf("game is paused")
+	}
+	if _, answered := player.Answers[questionID]; answered {
+		return fmt.Errorf("question already answered")
+	}
+	if gs.question(questionID) == nil {
+		return fmt.Errorf("unknown question %d", questionID)
+	}
+	if gs.SelfPaced {
+		if next := gs.NextUnanswered(player); next == nil || next.ID != questionID {
+			return fmt.Errorf("question %d is not your next question", questionID)
+		}
+		return nil
+	}
+	switch {
+	case gs.CurrentQuestion == nil || gs.CurrentQuestion.ID != questionID:
+		return fmt.Errorf("question %d is not open", questionID)
+	case gs.IsLocked || gs.IsRevealed:
+		return fmt.Errorf("question is locked")
+	}
+	if deadline := gs.Deadline(); !deadline.IsZero() && time.Now().After(deadline.Add(AnswerGrace)) {
+		return fmt.Errorf("time is up")
+	}
+	return nil
+}
 
-	// Update score if answer is correct
-	if answer == gs.CurrentQuestion.Correct {
-		player.Score += 10 // Basic scoring - 10 points per correct answer
+// NextUnanswered is the first question of the deck a player hasn't answered,
+// which is the one they are on in a self-paced game, or nil once they have
+// answered them all. Callers hold gs.Mu.
+func (gs *GameState) NextUnanswered(player *Player) *Question {
+	for i := range gs.Questions {
+		if _, answered := player.Answers[gs.Questions[i].ID]; !answered {
+			return &gs.Questions[i]
+		}
 	}
+	return nil
+}
+
+// timeAnswer records how long after the question opened a player answered it.
+// Callers hold gs.Mu.
+func (gs *GameState) timeAnswer(player *Player, questionID int) {
+	if gs.CurrentQuestion == nil || gs.CurrentQuestion.ID != questionID || gs.QuestionOpenedAt.IsZero() {
+		return
+	}
+	if player.ResponseTimes == nil {
+		player.ResponseTimes = make(map[int]int64)
+	}
+	player.ResponseTimes[questionID] = time.Since(gs.QuestionOpenedAt).Milliseconds()
+}
 
+// question finds a question of the game by ID. Callers hold gs.Mu.
+func (gs *GameState) question(questionID int) *Question {
+	for i := range gs.Questions {
+		if gs.Questions[i].ID == questionID {
+			return &gs.Questions[i]
+		}
+	}
 	return nil
 }
 
 func (gs *GameState) calculateFinalScores() {
+	gs.recomputeScores()
+}
+
+// PointsPerAnswer is what each right answer scores, unless its round says otherwise
+const PointsPerAnswer = 10
+
+// recomputeScores rescores every player. Callers hold gs.Mu.
+func (gs *GameState) recomputeScores() {
 	for _, player := range gs.Players {
-		// You can implement more complex scoring logic here
-		// For example, time bonuses, streaks, etc.
-
-		// Currently using basic scoring from submitted answers
-		finalScore := 0
-		for questionID, answer := range player.Answers {
-			// Find the question and check if the answer was correct
-			for _, q := range gs.Questions {
-				if q.ID == questionID && answer == q.Correct {
-					finalScore += 10
-				}
+		gs.scorePlayer(player)
+	}
+}
+
+// scorePlayer adds up a player's right answers to questions that aren't
+// void, on top of the host's manual adjustments. Callers hold gs.Mu.
+func (gs *GameState) scorePlayer(player *Player) {
+	// You can implement more complex scoring logic here
+	// For example, time bonuses, streaks, etc.
+	score := player.AdjustmentTotal()
+	for i := range gs.Questions {
+		q := &gs.Questions[i]
+		if !gs.Voided[q.ID] {
+			score += gs.QuestionPoints(player, q)
+		}
+	}
+	player.Score = score
+}
+
+// RoundOf returns the index in Rounds of the round a question belongs to, or
+// -1 when it isn't in one. Callers hold Mu.
+func (gs *GameState) RoundOf(questionID int) int {
+	for i := range gs.Rounds {
+		for _, id := range gs.Rounds[i].QuestionIDs {
+			if id == questionID {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// QuestionPoints is what a player's answer to q scores under its round's
+// scoring, ignoring voiding. Callers hold Mu.
+func (gs *GameState) QuestionPoints(player *Player, q *Question) int {
+	round := Round{}
+	if r := gs.RoundOf(q.ID); r >= 0 {
+		round = gs.Rounds[r]
+	}
+	points := round.PointsPerAnswer()
+	if !player.IsRight(q) {
+		if _, answered := player.Answers[q.ID]; answered && round.Scoring == ScoringPenalty {
+			return -points
+		}
+		return 0
+	}
+	if round.Scoring == ScoringSpeed && q.TimeLimit > 0 {
+		// Half the points for being right, the other half shrinking to
+		// nothing as the countdown runs out
+		if ms, timed := player.ResponseTimes[q.ID]; timed {
+			limit := int64(q.TimeLimit) * 1000
+			bonus := points / 2
+			if ms < limit {
+				return points - bonus + int(int64(bonus)*(limit-ms)/limit)
 			}
+			return points - bonus
 		}
-		player.Score = finalScore
 	}
+	return points
 }
 
-// GetGameStatus returns a snapshot of the current game state
+// IsRight reports whether a player gets the points for q: the host's ruling
+// if there is one, otherwise whether their answer is accepted. It ignores
+// voiding. Callers hold the game's Mu.
+func (p *Player) IsRight(q *Question) bool {
+	if ruling, ok := p.Rulings[q.ID]; ok {
+		return ruling
+	}
+	answer, answered := p.Answers[q.ID]
+	return answered && q.Accepts(answer)
+}
+
+// AdjustmentTotal adds up the host's manual score changes. Callers hold the game's Mu.
+func (p *Player) AdjustmentTotal() int {
+	total := 0
+	for _, adjustment := range p.Adjustments {
+		total += adjustment.Delta
+	}
+	return total
+}
+
+// GetGameStatus returns a snapshot of the current game state, copied so it
+// can be encoded after the lock is released
 func (gs *GameState) GetGameStatus() map[string]interface{} {
 	gs.Mu.RLock()
 	defer gs.Mu.RUnlock()
 
+	players := make(map[string]*Player, len(gs.Players))
+	for id, player := range gs.Players {
+		players[id] = player.Copy()
+	}
+	var question *Question
+	if gs.CurrentQuestion != nil {
+		question = &CopyQuestions([]Question{*gs.CurrentQuestion})[0]
+	}
 	return map[string]interface{}{
 		"id":        gs.ID,
 		"isActive":  gs.IsActive,
+		"isPaused":  gs.IsPaused,
+		"isLocked":  gs.IsLocked,
 		"round":     gs.Round,
-		"players":   gs.Players,
-		"question":  gs.CurrentQuestion,
+		"players":   players,
+		"question":  question,
 		"startTime": gs.StartTime,
 		"endTime":   gs.EndTime,
 	}
 }
 
-// Add methods to safely handle the WebSocket connection
-func (p *Player) SetConnection(conn *websocket.Conn) {
-	p.WSConn = conn
+// Transport is a live connection to a player's client. The websocket package
+// provides implementations for WebSocket and Server-Sent Events clients.
+type Transport interface {
+	WriteJSON(v interface{}) error
+	Ping() error
+	// Close ends the connection, passing code and reason on where the transport supports it
+	Close(code int, reason string) error
+}
+
+// Add methods to safely handle the player's connection
+func (p *Player) SetConnection(conn Transport) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn != nil && p.Conn != conn {
+		p.Conn.Close(CloseReplaced, "connected from another client")
+	}
+	p.Conn = conn
+	p.Status = PresenceConnected
+	p.LastSeen = time.Now()
 }
 
 func (p *Player) CloseConnection() {
-	if p.WSConn != nil {
-		p.WSConn.Close()
-		p.WSConn = nil
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn != nil {
+		p.Conn.Close(CloseNormal, "")
+		p.Conn = nil
+	}
+	p.Status = PresenceDisconnected
+}
+
+// IsConnected reports whether the player currently has an open connection
+func (p *Player) IsConnected() bool {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+	return p.Conn != nil
+}
+
+// DetachConnection closes conn and marks the player disconnected, unless the
+// player has already reconnected on a newer connection.
+func (p *Player) DetachConnection(conn Transport) bool {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn != conn {
+		return false
+	}
+	p.Conn.Close(CloseNormal, "")
+	p.Conn = nil
+	p.Status = PresenceDisconnected
+	return true
+}
+
+// CloseWithReason ends the player's connection, telling the client why
+func (p *Player) CloseWithReason(code int, reason string) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn == nil {
+		return
+	}
+	p.Conn.Close(code, reason)
+	p.Conn = nil
+	p.Status = PresenceDisconnected
+}
+
+// WriteJSON sends a message to the player. Transports allow only one
+// concurrent writer, so every write goes through here.
+func (p *Player) WriteJSON(v interface{}) error {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn == nil {
+		return fmt.Errorf("player %s is not connected", p.ID)
+	}
+	return p.Conn.WriteJSON(v)
+}
+
+// WritePing sends a keep-alive over the player's connection
+func (p *Player) WritePing() error {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn == nil {
+		return fmt.Errorf("player %s is not connected", p.ID)
+	}
+	return p.Conn.Ping()
+}
+
+// Touch records activity from the player. It reports true if the player was
+// previously away or disconnected and is now connected again.
+func (p *Player) Touch() bool {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	p.LastSeen = time.Now()
+	if p.Status == PresenceConnected {
+		return false
+	}
+	p.Status = PresenceConnected
+	return true
+}
+
+// SetPresence updates the player's presence and reports whether it changed
+func (p *Player) SetPresence(status PresenceStatus) bool {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Status == status {
+		return false
+	}
+	p.Status = status
+	return true
+}
+
+// SyncPresence takes presence reported by another server instance. It is
+// ignored while the player is connected to this one, which knows better.
+func (p *Player) SyncPresence(status PresenceStatus, lastSeen time.Time) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Conn != nil {
+		return
+	}
+	p.Status = status
+	p.LastSeen = lastSeen
+}
+
+// Presence returns the player's presence status and when they were last seen
+func (p *Player) Presence() (PresenceStatus, time.Time) {
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if p.Status == "" {
+		return PresenceDisconnected, p.LastSeen
+	}
+	return p.Status, p.LastSeen
+}
+
+// Copy returns a copy of the player with its own maps and no connection, safe
+// to encode after the game's lock is released. Callers hold the game's Mu.
+func (p *Player) Copy() *Player {
+	status, lastSeen := p.Presence()
+	return &Player{
+		ID:            p.ID,
+		Name:          p.Name,
+		Score:         p.Score,
+		Answers:       maps.Clone(p.Answers),
+		ResponseTimes: maps.Clone(p.ResponseTimes),
+		Adjustments:   slices.Clone(p.Adjustments),
+		Rulings:       maps.Clone(p.Rulings),
+		Anonymous:     p.Anonymous,
+		IP:            p.IP,
+		GameID:        p.GameID,
+		Status:        status,
+		LastSeen:      lastSeen,
+	}
+}
+
+// CopyQuestions copies a deck, including each question's accepted answers,
+// so it can be encoded after the game's lock is released. Callers hold the
+// game's Mu.
+func CopyQuestions(questions []Question) []Question {
+	deck := slices.Clone(questions)
+	for i := range deck {
+		deck[i].Options = slices.Clone(deck[i].Options)
+		deck[i].Accepted = slices.Clone(deck[i].Accepted)
+	}
+	return deck
+}
+
+// MergePlayer folds another instance's copy of a player into the local one
+// and rescores it. Answers, response times and rulings are merged by question
+// ID, so an answer recorded on either instance survives, and adjustments made
+// on either side are kept once each. Callers hold gs.Mu.
+func (gs *GameState) MergePlayer(local, remote *Player) {
+	for questionID, answer := range remote.Answers {
+		if _, answered := local.Answers[questionID]; !answered {
+			local.SubmitAnswer(questionID, answer)
+			if ms, timed := remote.ResponseTimes[questionID]; timed {
+				if local.ResponseTimes == nil {
+					local.ResponseTimes = make(map[int]int64)
+				}
+				local.ResponseTimes[questionID] = ms
+			}
+		}
+	}
+	if len(remote.Rulings) > 0 && local.Rulings == nil {
+		local.Rulings = make(map[int]bool)
+	}
+	// The host rules on one instance at a time, so the latest call wins
+	maps.Copy(local.Rulings, remote.Rulings)
+	for _, adjustment := range remote.Adjustments {
+		if !slices.ContainsFunc(local.Adjustments, adjustment.Equal) {
+			local.Adjustments = append(local.Adjustments, adjustment)
+		}
 	}
+	slices.SortStableFunc(local.Adjustments, func(a, b ScoreAdjustment) int { return a.At.Compare(b.At) })
+	gs.scorePlayer(local)
 }
 
 func (p *Player) SubmitAnswer(questionID int, answer string) {
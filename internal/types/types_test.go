@@ -0,0 +1,179 @@
+package types
+
+import "testing"
+
+func TestQuestionPoints(t *testing.T) {
+	question := Question{ID: 1, Text: "Q?", Options: []string{"a", "b"}, Correct: "1", TimeLimit: 10}
+	tests := []struct {
+		name   string
+		round  Round
+		answer string // empty when the player didn't answer
+		ms     int64  // response time, 0 for untimed
+		ruling *bool
+		want   int
+	}{
+		{name: "right", answer: "1", want: PointsPerAnswer},
+		{name: "wrong", answer: "2", want: 0},
+		{name: "unanswered", want: 0},
+		{name: "round points", round: Round{Points: 25}, answer: "1", want: 25},
+		{name: "penalty wrong", round: Round{Points: 20, Scoring: ScoringPenalty}, answer: "2", want: -20},
+		{name: "penalty unanswered", round: Round{Points: 20, Scoring: ScoringPenalty}, want: 0},
+		{name: "speed instant", round: Round{Points: 20, Scoring: ScoringSpeed}, answer: "1", ms: 0, want: 20},
+		{name: "speed halfway", round: Round{Points: 20, Scoring: ScoringSpeed}, answer: "1", ms: 5000, want: 15},
+		{name: "speed too late", round: Round{Points: 20, Scoring: ScoringSpeed}, answer: "1", ms: 12000, want: 10},
+		{name: "speed wrong", round: Round{Points: 20, Scoring: ScoringSpeed}, answer: "2", ms: 1000, want: 0},
+		{name: "ruled right", answer: "2", ruling: ptr(true), want: PointsPerAnswer},
+		{name: "ruled wrong", answer: "1", ruling: ptr(false), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			round := tt.round
+			round.QuestionIDs = []int{question.ID}
+			game := &GameState{Questions: []Question{question}, Rounds: []Round{round}}
+			player := &Player{Answers: map[int]string{}, ResponseTimes: map[int]int64{}, Rulings: map[int]bool{}}
+			if tt.answer != "" {
+				player.Answers[question.ID] = tt.answer
+				player.ResponseTimes[question.ID] = tt.ms
+			}
+			if tt.ruling != nil {
+				player.Rulings[question.ID] = *tt.ruling
+			}
+			if got := game.QuestionPoints(player, &game.Questions[0]); got != tt.want {
+				t.Errorf("QuestionPoints = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(b bool) *bool { return &b }
+
+// scoringGame has two questions and three players: Ann got both right, Bob
+// answered the first with b, Cy answered the first with a, b
+func scoringGame() *GameState {
+	return &GameState{
+		Questions: []Question{
+			{ID: 1, Text: "One?", Options: []string{"a", "b"}, Correct: "1"},
+			{ID: 2, Text: "Two?", Options: []string{"a", "b"}, Correct: "2"},
+		},
+		Players: map[string]*Player{
+			"ann": {ID: "ann", Answers: map[int]string{1: "1", 2: "2"}},
+			"bob": {ID: "bob", Answers: map[int]string{1: "2"}, Adjustments: []ScoreAdjustment{{Delta: 5}}},
+			"cy":  {ID: "cy", Answers: map[int]string{1: "1,2"}},
+		},
+	}
+}
+
+func scores(game *GameState) map[string]int {
+	scores := make(map[string]int, len(game.Players))
+	for id, player := range game.Players {
+		scores[id] = player.Score
+	}
+	return scores
+}
+
+func TestRescoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(game *GameState) error
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:   "nothing changed",
+			change: func(game *GameState) error { return nil },
+			want:   map[string]int{"ann": 20, "bob": 5, "cy": 0},
+		},
+		{
+			name: "void",
+			change: func(game *GameState) error {
+				_, err := game.VoidQuestion(1)
+				return err
+			},
+			want: map[string]int{"ann": 10, "bob": 5, "cy": 0},
+		},
+		{
+			name: "void twice",
+			change: func(game *GameState) error {
+				game.VoidQuestion(1)
+				_, err := game.VoidQuestion(1)
+				return err
+			},
+			want:    map[string]int{"ann": 10, "bob": 5, "cy": 0},
+			wantErr: true,
+		},
+		{
+			name: "accept another answer",
+			change: func(game *GameState) error {
+				_, err := game.AcceptAnswer(1, "2")
+				return err
+			},
+			want: map[string]int{"ann": 20, "bob": 15, "cy": 0},
+		},
+		{
+			name: "accept a combination",
+			change: func(game *GameState) error {
+				_, err := game.AcceptAnswer(1, "2,1")
+				return err
+			},
+			want: map[string]int{"ann": 20, "bob": 5, "cy": 10},
+		},
+		{
+			name: "accept the correct answer",
+			change: func(game *GameState) error {
+				_, err := game.AcceptAnswer(1, "1")
+				return err
+			},
+			want:    map[string]int{"ann": 20, "bob": 5, "cy": 0},
+			wantErr: true,
+		},
+		{
+			name: "rule an answer right",
+			change: func(game *GameState) error {
+				_, err := game.RuleAnswer("bob", 1, true)
+				return err
+			},
+			want: map[string]int{"ann": 20, "bob": 15, "cy": 0},
+		},
+		{
+			name: "rule a missing answer right",
+			change: func(game *GameState) error {
+				_, err := game.RuleAnswer("cy", 2, true)
+				return err
+			},
+			want: map[string]int{"ann": 20, "bob": 5, "cy": 10},
+		},
+		{
+			name: "deduct points",
+			change: func(game *GameState) error {
+				_, err := game.AdjustScore("ann", -3, "shouting answers")
+				return err
+			},
+			want: map[string]int{"ann": 17, "bob": 5, "cy": 0},
+		},
+		{
+			name: "accept on a void question",
+			change: func(game *GameState) error {
+				game.VoidQuestion(1)
+				_, err := game.AcceptAnswer(1, "2")
+				return err
+			},
+			want: map[string]int{"ann": 10, "bob": 5, "cy": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := scoringGame()
+			game.RecomputeScores()
+			err := tt.change(game)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			got := scores(game)
+			for id, want := range tt.want {
+				if got[id] != want {
+					t.Errorf("%s scored %d, want %d", id, got[id], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// queueSize is how many events can wait for a worker before new ones are dropped
+	queueSize = 256
+	// workers deliver events concurrently so one slow endpoint can't hold up the rest
+	workers = 4
+	// deliveryTimeout bounds a single attempt
+	deliveryTimeout = 10 * time.Second
+)
+
+// Options tune retries. The zero value uses 5 attempts starting at a 1s backoff.
+type Options struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Client      *http.Client
+}
+
+// Dispatcher queues events and delivers them to every endpoint that wants them
+type Dispatcher struct {
+	store   Store
+	opts    Options
+	events  chan Event
+	queue   chan job
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	closing sync.Once
+}
+
+type job struct {
+	endpoint Endpoint
+	event    Event
+	body     []byte
+}
+
+// NewDispatcher starts the delivery workers
+func NewDispatcher(store Store, opts Options) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: deliveryTimeout}
+	}
+
+	d := &Dispatcher{
+		store:  store,
+		opts:   opts,
+		events: make(chan Event, queueSize),
+		queue:  make(chan job, queueSize),
+		stop:   make(chan struct{}),
+	}
+	d.wg.Add(1)
+	go d.fanOut()
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Emit queues an event for every subscribed endpoint. It never blocks the
+// caller; if the queue is full the event is dropped and logged.
+func (d *Dispatcher) Emit(eventType string, gameID string, data interface{}) {
+	if d == nil {
+		return
+	}
+	event := Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		GameID:    gameID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	select {
+	case d.events <- event:
+	default:
+		slog.Warn("webhook queue is full, dropping event", "event", eventType, "event_id", event.ID)
+	}
+}
+
+// fanOut turns each event into a job per subscribed endpoint
+func (d *Dispatcher) fanOut() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case event := <-d.events:
+			endpoints, err := d.store.ListWebhooks()
+			if err != nil {
+				slog.Error("listing webhooks failed", "event", event.Type, "err", err)
+				continue
+			}
+			body, err := json.Marshal(event)
+			if err != nil {
+				slog.Error("encoding webhook event failed", "event", event.Type, "err", err)
+				continue
+			}
+			for _, endpoint := range endpoints {
+				if !endpoint.Wants(event.Type) {
+					continue
+				}
+				select {
+				case d.queue <- job{endpoint: endpoint, event: event, body: body}:
+				case <-d.stop:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close stops the workers, abandoning any retries still waiting
+func (d *Dispatcher) Close() error {
+	d.closing.Do(func() { close(d.stop) })
+	d.wg.Wait()
+	return nil
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case j := <-d.queue:
+			d.deliver(j)
+		}
+	}
+}
+
+// deliver tries a job until it succeeds, fails permanently or runs out of attempts
+func (d *Dispatcher) deliver(j job) {
+	for attempt := 1; attempt <= d.opts.MaxAttempts; attempt++ {
+		delivery, retry := d.attempt(j, attempt)
+		if err := d.store.RecordDelivery(delivery); err != nil {
+			slog.Error("recording webhook delivery failed", "event_id", j.event.ID, "err", err)
+		}
+		if delivery.Succeeded() || !retry || attempt == d.opts.MaxAttempts {
+			if !delivery.Succeeded() {
+				slog.Warn("webhook delivery failed", "event", j.event.Type, "event_id", j.event.ID,
+					"url", j.endpoint.URL, "attempts", attempt, "status", delivery.StatusCode, "err", delivery.Error)
+			}
+			return
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+}
+
+// attempt posts the event once and reports whether a failure is worth retrying
+func (d *Dispatcher) attempt(j job, attempt int) (Delivery, bool) {
+	delivery := Delivery{
+		EndpointID: j.endpoint.ID,
+		URL:        j.endpoint.URL,
+		EventID:    j.event.ID,
+		EventType:  j.event.Type,
+		Attempt:    attempt,
+		CreatedAt:  time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.endpoint.URL, bytes.NewReader(j.body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, false
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "party-trivia-webhooks/1")
+	req.Header.Set(HeaderEvent, j.event.Type)
+	req.Header.Set(HeaderDelivery, j.event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(j.endpoint.Secret, now, j.body))
+
+	res, err := d.opts.Client.Do(req)
+	delivery.Duration = time.Since(now)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, true
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	delivery.StatusCode = res.StatusCode
+	if delivery.Succeeded() {
+		return delivery, false
+	}
+	delivery.Error = fmt.Sprintf("endpoint answered %s", res.Status)
+	// Client errors other than throttling won't fix themselves
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+	return delivery, retry
+}
+
+// backoff doubles the wait after each attempt, with jitter so retries spread out
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.opts.BaseBackoff << (attempt - 1)
+	if wait <= 0 || wait > d.opts.MaxBackoff {
+		wait = d.opts.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(wait)/2 + 1))
+	return wait/2 + jitter
+}
@@ -0,0 +1,142 @@
+// Package webhook delivers game events to registered HTTP endpoints as signed
+// JSON, retrying failed deliveries with exponential backoff.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Event types sent to endpoints
+const (
+	EventGameCreated    = "game.created"
+	EventGameStarted    = "game.started"
+	EventQuestionOpened = "question.opened"
+	EventGameEnded      = "game.ended"
+	EventPlayerJoined   = "player.joined"
+)
+
+// EventTypes lists every event an endpoint can subscribe to
+var EventTypes = []string{
+	EventGameCreated,
+	EventGameStarted,
+	EventQuestionOpened,
+	EventGameEnded,
+	EventPlayerJoined,
+}
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-Trivia-Event"
+	HeaderDelivery  = "X-Trivia-Delivery"
+	HeaderTimestamp = "X-Trivia-Timestamp"
+	HeaderSignature = "X-Trivia-Signature"
+)
+
+// Event is the JSON body posted to endpoints
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	GameID    string      `json:"gameId"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Endpoint is a URL registered to receive events
+type Endpoint struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Events    []string // empty means every event
+	CreatedAt time.Time
+}
+
+// Wants reports whether the endpoint subscribed to eventType
+func (e Endpoint) Wants(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery records a single attempt to deliver an event to an endpoint
+type Delivery struct {
+	ID         int64
+	EndpointID int64
+	URL        string
+	EventID    string
+	EventType  string
+	Attempt    int
+	StatusCode int // zero when no response was received
+	Error      string
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
+
+// Succeeded reports whether the endpoint accepted the delivery
+func (d Delivery) Succeeded() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode <= 299
+}
+
+// Store persists endpoints and the delivery log
+type Store interface {
+	ListWebhooks() ([]Endpoint, error)
+	AddWebhook(endpoint Endpoint) (int64, error)
+	DeleteWebhook(id int64) error
+	RecordDelivery(delivery Delivery) error
+	RecentDeliveries(limit int) ([]Delivery, error)
+}
+
+// Sign returns the signature header value for a body sent at timestamp.
+// Receivers recompute HMAC-SHA256 over "<timestamp>.<body>" with the shared
+// secret and compare it in constant time.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign and rejects stale timestamps
+func Verify(secret string, timestampHeader string, signature string, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	timestamp := time.Unix(unix, 0)
+	if age := time.Since(timestamp); age > tolerance || age < -tolerance {
+		return fmt.Errorf("timestamp is outside the %s tolerance", tolerance)
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// NewSecret generates a random signing secret for a new endpoint
+func NewSecret() string {
+	return "whsec_" + randomHex(24)
+}
+
+func newEventID() string {
+	return "evt_" + randomHex(12)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			name:   "event",
+			secret: "whsec_test",
+			body:   `{"type":"game.started"}`,
+			want:   "sha256=55451e561ee854bb674c25529457b5bcd2d8482c6c2e08b62b8a48a668ac1530",
+		},
+		{
+			name:   "empty body",
+			secret: "whsec_test",
+			body:   "",
+			want:   "sha256=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, at, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"player.joined"}`)
+	now := time.Now()
+	stamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("whsec_test", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		ok        bool
+	}{
+		{"valid", "whsec_test", stamp, signature, body, true},
+		{"wrong secret", "whsec_other", stamp, signature, body, false},
+		{"tampered body", "whsec_test", stamp, signature, []byte(`{"type":"game.ended"}`), false},
+		{"replayed later", "whsec_test", strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), signature, body, false},
+		{"bad timestamp", "whsec_test", "yesterday", signature, body, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute)
+			if (err == nil) != tt.ok {
+				t.Errorf("Verify = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"richetechguy/internal/game"
+	"richetechguy/internal/types"
+)
+
+// checkAdminToken reports whether token matches the configured admin token.
+// Without a configured token nothing matches, so admin commands are refused.
+func checkAdminToken(configured string, token string) bool {
+	if configured == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(configured), []byte(token)) == 1
+}
+
+// handleAdminMessage runs a host command and returns the ack or error to send back
+func handleAdminMessage(msg incomingMessage, admin *adminConn, adminToken string, gameManager *game.GameManager, qm *game.QuestionManager) Message {
+	if msg.Type == TypeAuth {
+		var payload AuthPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid auth payload")
+		}
+		if adminToken == "" {
+			return NewError(msg.ID, ErrUnauthorized, "admin commands are disabled until ADMIN_TOKEN is set")
+		}
+		if !checkAdminToken(adminToken, payload.Token) {
+			return NewError(msg.ID, ErrUnauthorized, "invalid admin token")
+		}
+		if !admin.authenticated.Swap(true) {
+			defer sendGameStatus(admin, gameManager)
+		}
+		return NewAck(msg.ID, msg.Type)
+	}
+
+	if !admin.authenticated.Load() {
+		return NewError(msg.ID, ErrUnauthorized, "send an auth message before issuing commands")
+	}
+
+	var target GameCommandPayload
+	if err := json.Unmarshal(msg.Payload, &target); err != nil {
+		return NewError(msg.ID, ErrBadRequest, "invalid command payload")
+	}
+	gameState, err := gameManager.GetGame(target.GameID)
+	if err != nil {
+		return NewError(msg.ID, ErrGameNotFound, err.Error())
+	}
+
+	switch msg.Type {
+	case TypeStartGame:
+		if err := gameManager.StartGame(target.GameID, qm); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+		AnnounceGameStarted(gameState)
+	case TypePauseGame, TypeResumeGame, TypeNextQuestion, TypeLockQuestion, TypeRevealAnswer,
+		TypeSkipQuestion, TypeReopenQuestion:
+		if _, err := runHostAction(gameManager, gameState.ID, hostActions[msg.Type], 0); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+	case TypeExtendTimer:
+		var payload ExtendTimerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid extend payload")
+		}
+		if _, err := runHostAction(gameManager, gameState.ID, game.ActionExtend, payload.Seconds); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+	case TypeVoidQuestion:
+		var payload VoidQuestionPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid void payload")
+		}
+		if _, err := runHostAction(gameManager, gameState.ID, game.ActionVoid, payload.QuestionID); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+	case TypeKickPlayer:
+		var payload KickPlayerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid kick payload")
+		}
+		_, player, err := gameManager.KickPlayer(gameState.ID, payload.PlayerID, payload.Reason)
+		if player == nil {
+			return NewError(msg.ID, ErrPlayerNotFound, err.Error())
+		}
+		removePlayer(gameState, player, payload.Reason)
+		if err != nil {
+			// Saving failed, but the player is already gone
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+	case TypeBanPlayer:
+		var payload BanPlayerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid ban payload")
+		}
+		_, player, err := gameManager.BanPlayer(gameState.ID, payload.PlayerID, payload.Reason)
+		if player == nil {
+			return NewError(msg.ID, ErrPlayerNotFound, err.Error())
+		}
+		removePlayer(gameState, player, payload.Reason)
+		if err != nil {
+			// Saving failed, but the player is already gone
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+	case TypeRenamePlayer:
+		var payload RenamePlayerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid rename payload")
+		}
+		if _, err := gameManager.RenamePlayer(gameState.ID, payload.PlayerID, payload.Name); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+		announceLeaderboard(gameState)
+	case TypeAdjustScore:
+		var payload AdjustScorePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid score payload")
+		}
+		if _, _, err := gameManager.AdjustScore(gameState.ID, payload.PlayerID, payload.Delta, payload.Reason); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+		announceLeaderboard(gameState)
+	case TypeAcceptAnswer:
+		var payload AcceptAnswerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid accept payload")
+		}
+		if _, _, err := gameManager.AcceptAnswer(gameState.ID, payload.QuestionID, payload.Answer); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+		announceLeaderboard(gameState)
+	case TypeRuleAnswer:
+		var payload RuleAnswerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid ruling payload")
+		}
+		if _, err := gameManager.RuleAnswer(gameState.ID, payload.PlayerID, payload.QuestionID, payload.Correct); err != nil {
+			return NewError(msg.ID, ErrCommandFailed, err.Error())
+		}
+		announceLeaderboard(gameState)
+	default:
+		return NewError(msg.ID, ErrUnknownType, fmt.Sprintf("unknown command %q", msg.Type))
+	}
+
+	// Keep every open dashboard and player screen in step with the command's effect
+	syncGame(gameState)
+	if msg.Type != TypeStartGame {
+		// AnnounceGameStarted has already pushed the new screens
+		PushViews(gameState)
+	}
+	refreshAdmins(gameState)
+	return NewAck(msg.ID, msg.Type)
+}
+
+// hostActions maps admin commands onto the game actions they run
+var hostActions = map[string]game.HostAction{
+	TypePauseGame:      game.ActionPause,
+	TypeResumeGame:     game.ActionResume,
+	TypeNextQuestion:   game.ActionNext,
+	TypeLockQuestion:   game.ActionLock,
+	TypeRevealAnswer:   game.ActionReveal,
+	TypeSkipQuestion:   game.ActionSkip,
+	TypeReopenQuestion: game.ActionReopen,
+}
+
+// RunHostAction carries out a host action from the dashboard and brings every
+// player, presenter and admin screen up to date. value is the seconds to
+// extend by, or the question to void.
+func RunHostAction(gm *game.GameManager, gameID string, action game.HostAction, value int) (*types.GameState, error) {
+	gameState, err := runHostAction(gm, gameID, action, value)
+	if err != nil {
+		return nil, err
+	}
+	PushViews(gameState)
+	refreshAdmins(gameState)
+	return gameState, nil
+}
+
+// runHostAction carries out a host action and tells players what happened
+func runHostAction(gm *game.GameManager, gameID string, action game.HostAction, value int) (*types.GameState, error) {
+	var gameState *types.GameState
+	var question *types.Question
+	var err error
+	switch action {
+	case game.ActionPause:
+		gameState, err = gm.PauseGame(gameID)
+	case game.ActionResume:
+		gameState, err = gm.ResumeGame(gameID)
+	case game.ActionNext:
+		gameState, question, err = gm.NextQuestion(gameID)
+	case game.ActionLock:
+		gameState, question, err = gm.LockQuestion(gameID)
+	case game.ActionReveal:
+		gameState, question, err = gm.RevealAnswer(gameID)
+	case game.ActionSkip:
+		gameState, question, err = gm.SkipQuestion(gameID)
+	case game.ActionReopen:
+		gameState, question, err = gm.ReopenQuestion(gameID)
+	case game.ActionExtend:
+		gameState, question, err = gm.ExtendTimer(gameID, value)
+	case game.ActionVoid:
+		gameState, question, err = gm.VoidQuestion(gameID, value)
+	default:
+		return nil, fmt.Errorf("unknown host action: %s", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	announceHostAction(gameState, action, question)
+	return gameState, nil
+}
+
+// announceHostAction tells players what a host action did to the game.
+// question is the one the action opened, locked or revealed.
+func announceHostAction(gameState *types.GameState, action game.HostAction, question *types.Question) {
+	switch action {
+	case game.ActionPause:
+		BroadcastToPlayers(gameState, Message{
+			Type:    TypeGameState,
+			Payload: GameStatePayload{State: "paused", Message: "The host paused the game"},
+		})
+	case game.ActionResume:
+		BroadcastToPlayers(gameState, Message{
+			Type:    TypeGameState,
+			Payload: GameStatePayload{State: "active", Message: "The game has resumed!"},
+		})
+	case game.ActionNext, game.ActionSkip, game.ActionReopen:
+		if question == nil {
+			// The question was the last of its round or of the game
+			announceIntermission(gameState)
+			break
+		}
+		gameState.Mu.RLock()
+		message := fmt.Sprintf("Question %d", gameState.Round)
+		if action == game.ActionReopen {
+			message += " is open again"
+		}
+		payload := QuestionPayload{
+			State:     "active",
+			Message:   message,
+			Questions: PlayerQuestions(gameState.Questions),
+			GameID:    gameState.ID,
+			Current:   NewPlayerQuestion(question),
+		}
+		gameState.Mu.RUnlock()
+		BroadcastToPlayers(gameState, Message{Type: TypeQuestion, Payload: payload})
+	case game.ActionLock:
+		BroadcastToPlayers(gameState, Message{
+			Type:    TypeQuestionLocked,
+			Payload: QuestionLockedPayload{GameID: gameState.ID, QuestionID: question.ID},
+		})
+	case game.ActionReveal:
+		BroadcastToPlayers(gameState, Message{
+			Type:    TypeReveal,
+			Payload: RevealPayload{GameID: gameState.ID, QuestionID: question.ID, Correct: question.Correct},
+		})
+		announceLeaderboard(gameState)
+	case game.ActionVoid:
+		// Everyone who got it right just lost the points
+		announceLeaderboard(gameState)
+	}
+}
+
+// announceIntermission tells players a round ended, when the game went into
+// the break after it
+func announceIntermission(gameState *types.GameState) {
+	intermission := game.BuildIntermission(gameState)
+	if intermission == nil {
+		return
+	}
+	BroadcastToPlayers(gameState, Message{
+		Type:    TypeIntermission,
+		Payload: intermissionPayload(gameState.ID, intermission),
+	})
+	announceLeaderboard(gameState)
+}
+
+// AnnounceScores brings leaderboards everywhere up to date after the host
+// changed scores or names from the dashboard
+func AnnounceScores(gameState *types.GameState) {
+	announceLeaderboard(gameState)
+	PushViews(gameState)
+	refreshAdmins(gameState)
+}
+
+// AnnounceRemoval disconnects a player the host kicked or banned from the
+// dashboard and updates everyone else's roster
+func AnnounceRemoval(gameState *types.GameState, player *types.Player, reason string) {
+	removePlayer(gameState, player, reason)
+	announceLeaderboard(gameState)
+	refreshAdmins(gameState)
+}
+
+// removePlayer closes a removed player's connection, wherever it is, and
+// tells the rest of the game they left
+func removePlayer(gameState *types.GameState, player *types.Player, reason string) {
+	if reason == "" {
+		reason = "Removed by the host"
+	}
+	KickPlayer(gameState, player, reason)
+	broadcastPlayerLeft(gameState, player)
+}
+
+// refreshAdmins tells every dashboard to reload a game's roster
+func refreshAdmins(gameState *types.GameState) {
+	BroadcastToAdmins(Message{
+		Type: TypePlayerList,
+		Payload: PlayerListPayload{
+			GameID:   gameState.ID,
+			Players:  copyPlayers(gameState),
+			IsActive: isActive(gameState),
+		},
+	})
+}
+
+// copyPlayers copies a game's roster for a message. Messages are encoded
+// after the game's lock is released, so they can't hold the live players.
+func copyPlayers(gameState *types.GameState) map[string]*types.Player {
+	gameState.Mu.RLock()
+	defer gameState.Mu.RUnlock()
+	players := make(map[string]*types.Player, len(gameState.Players))
+	for id, player := range gameState.Players {
+		players[id] = player.Copy()
+	}
+	return players
+}
+
+// publicPlayers copies a game's roster for the players, leaving out everyone's
+// answers and the host's rulings and adjustments
+func publicPlayers(gameState *types.GameState) map[string]*types.Player {
+	players := copyPlayers(gameState)
+	for _, player := range players {
+		player.Answers = map[int]string{}
+		player.ResponseTimes = nil
+		player.Rulings = nil
+		player.Adjustments = nil
+	}
+	return players
+}
+
+func isActive(gameState *types.GameState) bool {
+	gameState.Mu.RLock()
+	defer gameState.Mu.RUnlock()
+	return gameState.IsActive
+}
+
+// announceLeaderboard sends the current rankings to a game's players and to admins
+func announceLeaderboard(gameState *types.GameState) {
+	msg := Message{
+		Type: TypeLeaderboard,
+		Payload: LeaderboardPayload{
+			GameID:   gameState.ID,
+			Rankings: game.BuildLeaderboard(gameState).Rankings,
+		},
+	}
+	BroadcastToPlayers(gameState, msg)
+	BroadcastToAdmins(msg)
+}
+
+// AnnounceGameStarted tells players and admins that a game has started
+func AnnounceGameStarted(gameState *types.GameState) {
+	// Broadcast to players
+	BroadcastToPlayers(gameState, Message{
+		Type: TypeGameState,
+		Payload: GameStatePayload{
+			State:   "active",
+			Message: "Game has started!",
+			Players: publicPlayers(gameState),
+		},
+	})
+
+	PushViews(gameState)
+
+	// Broadcast to admins
+	BroadcastToAdmins(Message{
+		Type: TypePlayerList,
+		Payload: PlayerListPayload{
+			GameID:   gameState.ID,
+			Players:  copyPlayers(gameState),
+			IsActive: true,
+		},
+	})
+}
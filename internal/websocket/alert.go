@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"richetechguy/internal/ratelimit"
+	"time"
+)
+
+// alertLimiter stops a flood of violations from turning into a flood of alerts
+var alertLimiter = ratelimit.NewLimiter(ratelimit.Rate{Count: 1, Per: 30 * time.Second})
+
+// AlertAdmins warns every admin dashboard about trouble caused by source. Repeats
+// of the same code from the same source are dropped for a while.
+func AlertAdmins(code ErrorCode, message string, gameID string, source string) {
+	if !alertLimiter.Allow(string(code) + "|" + source) {
+		return
+	}
+	BroadcastToAdmins(Message{
+		Type: TypeAlert,
+		Payload: AlertPayload{
+			Code:    code,
+			Message: message,
+			GameID:  gameID,
+			Source:  source,
+		},
+	})
+}
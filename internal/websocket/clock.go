@@ -0,0 +1,33 @@
+package websocket
+
+import (
+	"log/slog"
+	"richetechguy/internal/game"
+	"time"
+)
+
+// clockInterval is how often RunClock looks for timers that ran out
+const clockInterval = time.Second
+
+// RunClock moves games on as their timers run out, locking questions whose
+// countdown is over and opening the next round when an intermission ends, so
+// that a game keeps time even when the host doesn't. It never returns.
+func RunClock(gm *game.GameManager) {
+	ticker := time.NewTicker(clockInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for gameID := range gm.GetAllGames() {
+			gameState, action, question, err := gm.ExpireTimers(gameID)
+			if err != nil {
+				slog.Error("expiring game timers failed", "game_id", gameID, "err", err)
+				continue
+			}
+			if action == "" {
+				continue
+			}
+			announceHostAction(gameState, action, question)
+			PushViews(gameState)
+			refreshAdmins(gameState)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log/slog"
+	"richetechguy/internal/broker"
+	"richetechguy/internal/game"
+	"richetechguy/internal/metrics"
+	"richetechguy/internal/types"
+)
+
+// manager is set by UseBroker; until then broadcasts only reach this process
+var manager *game.GameManager
+
+// playersEvent is the payload published on broker.TopicPlayers
+type playersEvent struct {
+	GameID  string          `json:"gameId"`
+	Message json.RawMessage `json:"message,omitempty"`
+	// Kick asks whichever instance holds the player's connection to close it
+	Kick *kickEvent `json:"kick,omitempty"`
+	// Views asks every instance to re-render its players' and presenters' screens
+	Views bool `json:"views,omitempty"`
+	// Presenters asks every instance to re-render only its presenter screens
+	Presenters bool `json:"presenters,omitempty"`
+}
+
+type kickEvent struct {
+	PlayerID string `json:"playerId"`
+	Reason   string `json:"reason"`
+}
+
+// UseBroker routes broadcasts through the game manager's broker, so players
+// and admins connected to other instances receive them too.
+func UseBroker(gameManager *game.GameManager) {
+	manager = gameManager
+	gameManager.Broker.Subscribe(broker.TopicPlayers, func(event broker.Event) {
+		var payload playersEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			slog.Error("decoding players event failed", "event_id", event.ID, "err", err)
+			return
+		}
+		gameState, err := gameManager.GetGame(payload.GameID)
+		if err != nil {
+			// The game hasn't been replicated to this instance yet
+			return
+		}
+		if payload.Kick != nil {
+			// The publishing instance already kicked its own connection
+			if event.Origin != gameManager.Broker.InstanceID() {
+				if player, err := gameState.RemovePlayer(payload.Kick.PlayerID); err == nil {
+					kickLocal(player, payload.Kick.Reason)
+				}
+			}
+			return
+		}
+		if payload.Views {
+			deliverViews(gameState)
+			return
+		}
+		if payload.Presenters {
+			deliverPresenterViews(gameState)
+			return
+		}
+		deliverToPlayers(gameState, payload.Message)
+	})
+	gameManager.Broker.Subscribe(broker.TopicAdmins, func(event broker.Event) {
+		deliverToAdmins(json.RawMessage(event.Payload))
+	})
+}
+
+// syncGame publishes a game's state to other instances
+func syncGame(gameState *types.GameState) {
+	if manager != nil {
+		manager.Sync(gameState)
+	}
+}
+
+// BroadcastToPlayers sends msg to every connected player of a game, whichever
+// instance and transport they are connected through
+func BroadcastToPlayers(gameState *types.GameState, msg Message) {
+	metrics.Broadcasts.Inc("players")
+	if manager == nil {
+		deliverToPlayers(gameState, msg)
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("encoding message for players failed", "game_id", gameState.ID, "type", msg.Type, "err", err)
+		return
+	}
+	payload, _ := json.Marshal(playersEvent{GameID: gameState.ID, Message: data})
+	if err := manager.Broker.Publish(broker.TopicPlayers, payload); err != nil {
+		slog.Error("publishing message for players failed", "game_id", gameState.ID, "type", msg.Type, "err", err)
+	}
+}
+
+// BroadcastToAdmins sends msg to every admin dashboard on every instance
+func BroadcastToAdmins(msg Message) {
+	metrics.Broadcasts.Inc("admins")
+	if manager == nil {
+		deliverToAdmins(msg)
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("encoding message for admins failed", "type", msg.Type, "err", err)
+		return
+	}
+	if err := manager.Broker.Publish(broker.TopicAdmins, data); err != nil {
+		slog.Error("publishing message for admins failed", "type", msg.Type, "err", err)
+	}
+}
+
+// deliverToPlayers writes msg to the players connected to this instance
+func deliverToPlayers(gameState *types.GameState, msg interface{}) {
+	gameState.Mu.RLock()
+	players := make([]*types.Player, 0, len(gameState.Players))
+	for _, player := range gameState.Players {
+		players = append(players, player)
+	}
+	gameState.Mu.RUnlock()
+
+	for _, player := range players {
+		if player.IsConnected() {
+			if err := player.WriteJSON(msg); err != nil {
+				metrics.DroppedMessages.Inc("players")
+				slog.Warn("broadcast to player failed", "game_id", gameState.ID, "player_id", player.ID, "err", err)
+				// The connection's handler notices the close and records the disconnect
+				player.CloseConnection()
+			}
+		}
+	}
+	deliverToSpectators(gameState, msg)
+}
+
+// deliverToAdmins writes msg to the admin sockets connected to this instance
+func deliverToAdmins(msg interface{}) {
+	adminMutex.RLock()
+	admins := make([]*adminConn, 0, len(adminConnections))
+	for admin := range adminConnections {
+		if admin.authenticated.Load() {
+			admins = append(admins, admin)
+		}
+	}
+	adminMutex.RUnlock()
+
+	for _, admin := range admins {
+		if err := admin.WriteJSON(msg); err != nil {
+			metrics.DroppedMessages.Inc("admins")
+			slog.Warn("broadcast to admin failed", "err", err)
+			admin.conn.Close()
+			adminMutex.Lock()
+			delete(adminConnections, admin)
+			adminMutex.Unlock()
+		}
+	}
+}
+
+// KickPlayer tells a player why they were removed and closes their connection,
+// on whichever instance it is held
+func KickPlayer(gameState *types.GameState, player *types.Player, reason string) {
+	kickLocal(player, reason)
+	if manager == nil {
+		return
+	}
+
+	payload, _ := json.Marshal(playersEvent{
+		GameID: gameState.ID,
+		Kick:   &kickEvent{PlayerID: player.ID, Reason: reason},
+	})
+	if err := manager.Broker.Publish(broker.TopicPlayers, payload); err != nil {
+		slog.Error("publishing kick failed", "game_id", gameState.ID, "player_id", player.ID, "err", err)
+	}
+}
+
+func kickLocal(player *types.Player, reason string) {
+	if !player.IsConnected() {
+		return
+	}
+	player.WriteJSON(Message{Type: TypeKicked, Payload: KickedPayload{Reason: reason}})
+	player.CloseWithReason(types.CloseKicked, reason)
+}
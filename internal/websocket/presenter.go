@@ -0,0 +1,185 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"richetechguy/internal/broker"
+	"richetechguy/internal/game"
+	"richetechguy/internal/metrics"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/ratelimit"
+	"richetechguy/internal/template"
+	"richetechguy/internal/types"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// presenterConn is a read-only big screen following one game
+type presenterConn struct {
+	conn   *websocket.Conn
+	gameID string
+	mu     sync.Mutex
+}
+
+func (p *presenterConn) WriteJSON(v interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return p.conn.WriteJSON(v)
+}
+
+func (p *presenterConn) WritePing() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+var (
+	presenterConnections = make(map[*presenterConn]bool)
+	presenterMutex       sync.RWMutex
+)
+
+func init() {
+	metrics.PresenterConnections.SetSource(func() []metrics.Sample {
+		presenterMutex.RLock()
+		defer presenterMutex.RUnlock()
+		return []metrics.Sample{{Value: float64(len(presenterConnections))}}
+	})
+}
+
+// HandlePresenterWebSocket streams a game's presenter view to a big screen.
+// It needs the game's presenter token rather than admin credentials, and
+// ignores anything the screen sends.
+func HandlePresenterWebSocket(gameManager *game.GameManager, limits *ratelimit.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowConnection(w, r, limits) {
+			return
+		}
+		gameState, err := gameManager.GetGame(r.URL.Query().Get("gameId"))
+		if err != nil || !game.CheckPresenterToken(gameState, r.URL.Query().Get("token")) {
+			http.Error(w, "Invalid presenter link", http.StatusForbidden)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			middleware.Logger(r.Context()).Warn("websocket upgrade failed", "err", err)
+			return
+		}
+		conn.SetReadLimit(limits.Config.MaxMessageBytes)
+		presenter := &presenterConn{conn: conn, gameID: gameState.ID}
+		middleware.Annotate(r.Context(), "game_id", gameState.ID)
+
+		presenterMutex.Lock()
+		presenterConnections[presenter] = true
+		presenterMutex.Unlock()
+
+		done := make(chan struct{})
+		defer func() {
+			close(done)
+			presenterMutex.Lock()
+			delete(presenterConnections, presenter)
+			presenterMutex.Unlock()
+			conn.Close()
+		}()
+
+		if err := sendPresenterView(gameState, presenter); err != nil {
+			return
+		}
+
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+			return nil
+		})
+		go func() {
+			ticker := time.NewTicker(pingPeriod)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := presenter.WritePing(); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				break
+			}
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+		}
+	}
+}
+
+// PushPresenters re-renders a game's presenter view on every instance. It is
+// cheaper than PushViews for changes only the big screen shows, like the
+// number of answers in.
+func PushPresenters(gameState *types.GameState) {
+	metrics.Broadcasts.Inc("presenters")
+	if manager == nil {
+		deliverPresenterViews(gameState)
+		return
+	}
+
+	payload, _ := json.Marshal(playersEvent{GameID: gameState.ID, Presenters: true})
+	if err := manager.Broker.Publish(broker.TopicPlayers, payload); err != nil {
+		slog.Error("publishing presenter refresh failed", "game_id", gameState.ID, "err", err)
+	}
+}
+
+// deliverPresenterViews sends the game's presenter view to the screens connected to this instance
+func deliverPresenterViews(gameState *types.GameState) {
+	presenterMutex.RLock()
+	var presenters []*presenterConn
+	for presenter := range presenterConnections {
+		if presenter.gameID == gameState.ID {
+			presenters = append(presenters, presenter)
+		}
+	}
+	presenterMutex.RUnlock()
+	if len(presenters) == 0 {
+		return
+	}
+
+	html, err := renderPresenterView(gameState)
+	if err != nil {
+		slog.Error("rendering presenter view failed", "game_id", gameState.ID, "err", err)
+		return
+	}
+	msg := Message{Type: TypeView, Payload: ViewPayload{HTML: html}}
+	for _, presenter := range presenters {
+		if err := presenter.WriteJSON(msg); err != nil {
+			metrics.DroppedMessages.Inc("presenters")
+			slog.Warn("sending view to presenter failed", "game_id", gameState.ID, "err", err)
+			// The read loop notices the close and unregisters the screen
+			presenter.conn.Close()
+		}
+	}
+}
+
+func sendPresenterView(gameState *types.GameState, presenter *presenterConn) error {
+	html, err := renderPresenterView(gameState)
+	if err != nil {
+		return err
+	}
+	return presenter.WriteJSON(Message{Type: TypeView, Payload: ViewPayload{HTML: html}})
+}
+
+func renderPresenterView(gameState *types.GameState) (string, error) {
+	var buf bytes.Buffer
+	view := game.BuildPresenterView(gameState)
+	if err := template.PresenterView(view, true).Render(context.Background(), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
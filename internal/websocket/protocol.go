@@ -0,0 +1,422 @@
+package websocket
+
+import (
+	"encoding/json"
+	"richetechguy/internal/game"
+	"richetechguy/internal/types"
+	"time"
+)
+
+// ProtocolVersion is bumped whenever a message in the catalog changes shape
+const ProtocolVersion = 1
+
+// Server -> client message types
+const (
+	TypeWelcome        = "welcome"
+	TypeAck            = "ack"
+	TypeError          = "error"
+	TypePlayerJoined   = "playerJoined"
+	TypePlayerLeft     = "playerLeft"
+	TypeGameState      = "gameState"
+	TypeQuestion       = "question"
+	TypePlayerList     = "playerList"
+	TypePlayerPresence = "playerPresence"
+	TypePlayerAnswered = "playerAnswered"
+	TypeGameStatus     = "gameStatus"
+	TypeQuestionLocked = "questionLocked"
+	TypeReveal         = "reveal"
+	TypeLeaderboard    = "leaderboard"
+	TypeKicked         = "kicked"
+	TypeAlert          = "alert"
+	TypeView           = "view"
+	TypeSpectators     = "spectators"
+	TypeIntermission   = "intermission"
+)
+
+// Client -> server message types
+const (
+	TypeHello  = "hello"
+	TypeAnswer = "answer"
+)
+
+// Admin client -> server command types
+const (
+	TypeAuth           = "auth"
+	TypeStartGame      = "startGame"
+	TypePauseGame      = "pauseGame"
+	TypeResumeGame     = "resumeGame"
+	TypeNextQuestion   = "nextQuestion"
+	TypeLockQuestion   = "lockQuestion"
+	TypeRevealAnswer   = "revealAnswer"
+	TypeKickPlayer     = "kickPlayer"
+	TypeBanPlayer      = "banPlayer"
+	TypeRenamePlayer   = "renamePlayer"
+	TypeAdjustScore    = "adjustScore"
+	TypeSkipQuestion   = "skipQuestion"
+	TypeReopenQuestion = "reopenQuestion"
+	TypeExtendTimer    = "extendTimer"
+	TypeVoidQuestion   = "voidQuestion"
+	TypeAcceptAnswer   = "acceptAnswer"
+	TypeRuleAnswer     = "ruleAnswer"
+)
+
+// ErrorCode identifies why the server rejected a client message
+type ErrorCode string
+
+const (
+	ErrBadRequest         ErrorCode = "bad_request"
+	ErrUnknownType        ErrorCode = "unknown_type"
+	ErrUnsupportedVersion ErrorCode = "unsupported_version"
+	ErrPlayerNotFound     ErrorCode = "player_not_found"
+	ErrInvalidAnswer      ErrorCode = "invalid_answer"
+	ErrUnauthorized       ErrorCode = "unauthorized"
+	ErrGameNotFound       ErrorCode = "game_not_found"
+	ErrCommandFailed      ErrorCode = "command_failed"
+	ErrRateLimited        ErrorCode = "rate_limited"
+	ErrGameFull           ErrorCode = "game_full"
+	ErrBanned             ErrorCode = "banned"
+	ErrInvalidName        ErrorCode = "invalid_name"
+	ErrGameClosed         ErrorCode = "game_closed" // started without late joins, or ended
+	ErrMessageTooLarge    ErrorCode = "message_too_large"
+)
+
+// Message is the envelope for everything sent over a socket. ID is chosen by
+// the client on requests and echoed back on the matching ack or error.
+type Message struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	Payload interface{} `json:"payload"`
+}
+
+// incomingMessage defers payload decoding until the type is known
+type incomingMessage struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// WelcomePayload is sent as soon as a player socket is attached
+type WelcomePayload struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	GameID          string `json:"gameId"`
+	PlayerID        string `json:"playerId"`
+	Name            string `json:"name"`
+	Spectator       bool   `json:"spectator,omitempty"` // watching only; PlayerID and Name are empty
+}
+
+// AckPayload confirms the client request with the same envelope ID was accepted
+type AckPayload struct {
+	Type string `json:"type"`
+}
+
+// ErrorPayload explains why a client request was rejected
+type ErrorPayload struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// PlayersPayload carries the full player roster of a game
+type PlayersPayload struct {
+	Players map[string]*types.Player `json:"players"`
+}
+
+// PlayerLeftPayload is sent to the remaining players when someone disconnects
+type PlayerLeftPayload struct {
+	PlayerID string                   `json:"playerID"`
+	Players  map[string]*types.Player `json:"players"`
+}
+
+// GameStatePayload announces a change in the game lifecycle
+type GameStatePayload struct {
+	State   string                   `json:"state"`
+	Message string                   `json:"message"`
+	Players map[string]*types.Player `json:"players,omitempty"`
+}
+
+// QuestionPayload hands the question deck to players
+type QuestionPayload struct {
+	State     string           `json:"state"`
+	Message   string           `json:"message"`
+	Questions []PlayerQuestion `json:"questions"`
+	GameID    string           `json:"gameId"`
+	Current   *PlayerQuestion  `json:"current,omitempty"` // set when the host advances question by question
+}
+
+// PlayerQuestion is a question as players receive it. The answer stays on
+// the server until the reveal.
+type PlayerQuestion struct {
+	ID        int                `json:"id"`
+	Text      string             `json:"text"`
+	Options   []string           `json:"options"`
+	Type      types.QuestionType `json:"type"`
+	TimeLimit int                `json:"timeLimit,omitempty"`
+	Media     string             `json:"media,omitempty"`
+}
+
+// NewPlayerQuestion strips a question's answers for sending to players
+func NewPlayerQuestion(q *types.Question) *PlayerQuestion {
+	if q == nil {
+		return nil
+	}
+	return &PlayerQuestion{ID: q.ID, Text: q.Text, Options: q.Options, Type: q.Type, TimeLimit: q.TimeLimit, Media: q.Media}
+}
+
+// PlayerQuestions strips a deck's answers for sending to players
+func PlayerQuestions(questions []types.Question) []PlayerQuestion {
+	deck := make([]PlayerQuestion, len(questions))
+	for i := range questions {
+		deck[i] = *NewPlayerQuestion(&questions[i])
+	}
+	return deck
+}
+
+// QuestionLockedPayload tells players the current question no longer takes answers
+type QuestionLockedPayload struct {
+	GameID     string `json:"gameId"`
+	QuestionID int    `json:"questionId"`
+}
+
+// RevealPayload shows players the correct answer to a question
+type RevealPayload struct {
+	GameID     string `json:"gameId"`
+	QuestionID int    `json:"questionId"`
+	Correct    string `json:"correct"`
+}
+
+// LeaderboardPayload ranks a game's players after a reveal
+type LeaderboardPayload struct {
+	GameID   string         `json:"gameId"`
+	Rankings []game.Ranking `json:"rankings"`
+}
+
+// IntermissionPayload starts the break after a round, with the round's
+// standings and what comes next
+type IntermissionPayload struct {
+	GameID    string         `json:"gameId"`
+	Round     types.Round    `json:"round"`
+	Next      *types.Round   `json:"next,omitempty"`
+	Until     time.Time      `json:"until"`
+	Standings []game.Ranking `json:"standings"`
+}
+
+func intermissionPayload(gameID string, intermission *game.Intermission) IntermissionPayload {
+	return IntermissionPayload{
+		GameID:    gameID,
+		Round:     intermission.Round,
+		Next:      intermission.Next,
+		Until:     intermission.Until,
+		Standings: intermission.Standings,
+	}
+}
+
+// KickedPayload is the last message a removed player receives
+type KickedPayload struct {
+	Reason string `json:"reason"`
+}
+
+// PlayerListPayload tells the admin dashboard to refresh its roster
+type PlayerListPayload struct {
+	GameID   string                   `json:"gameId"`
+	Players  map[string]*types.Player `json:"players"`
+	IsActive bool                     `json:"isActive"`
+}
+
+// SpectatorsPayload tells the admin dashboard how many spectators a game has
+type SpectatorsPayload struct {
+	GameID string `json:"gameId"`
+	Count  int    `json:"count"`
+}
+
+// PlayerPresencePayload reports a player's connection state to the admin dashboard
+type PlayerPresencePayload struct {
+	GameID   string               `json:"gameId"`
+	PlayerID string               `json:"playerId"`
+	Status   types.PresenceStatus `json:"status"`
+	LastSeen time.Time            `json:"lastSeen"`
+	IsActive bool                 `json:"isActive"`
+}
+
+// PlayerAnsweredPayload tells the admin dashboard a player submitted an answer
+type PlayerAnsweredPayload struct {
+	GameID     string `json:"gameId"`
+	PlayerID   string `json:"playerId"`
+	QuestionID int    `json:"questionId"`
+	Score      int    `json:"score"`
+}
+
+// GameStatusPayload is the snapshot sent to admins when they connect
+type GameStatusPayload struct {
+	GameID string                 `json:"gameId"`
+	Status map[string]interface{} `json:"status"`
+}
+
+// HelloPayload opens the handshake with the protocol version the client speaks
+type HelloPayload struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+// AnswerPayload submits a player's answer to a question
+type AnswerPayload struct {
+	QuestionID int    `json:"questionId,omitempty"` // omitted for the game's current question
+	Answer     string `json:"answer"`
+}
+
+// AlertPayload warns admins about abuse or other trouble in a game
+type AlertPayload struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	GameID  string    `json:"gameId,omitempty"`
+	Source  string    `json:"source,omitempty"` // the IP or player responsible
+}
+
+// ViewPayload is a server-rendered fragment of the player page. Each top-level
+// element carries an id and hx-swap-oob, like htmx's ws extension expects.
+type ViewPayload struct {
+	HTML string `json:"html"`
+}
+
+// AuthPayload authenticates an admin socket before it may send commands
+type AuthPayload struct {
+	Token string `json:"token"`
+}
+
+// GameCommandPayload targets a host command at a game
+type GameCommandPayload struct {
+	GameID string `json:"gameId"`
+}
+
+// KickPlayerPayload removes a player from a game
+type KickPlayerPayload struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// BanPlayerPayload removes a player from a game and keeps their player ID and
+// address out of it for the rest of the game
+type BanPlayerPayload struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// RenamePlayerPayload replaces a player's name
+type RenamePlayerPayload struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+}
+
+// AdjustScorePayload adds Delta points (negative to deduct) to a player. The
+// reason is required and shows in the player's score breakdown.
+type AdjustScorePayload struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Delta    int    `json:"delta"`
+	Reason   string `json:"reason"`
+}
+
+// AcceptAnswerPayload rules another answer to a question right, in the
+// 1-based option form of Question.Correct
+type AcceptAnswerPayload struct {
+	GameID     string `json:"gameId"`
+	QuestionID int    `json:"questionId"`
+	Answer     string `json:"answer"`
+}
+
+// RuleAnswerPayload marks one player's answer to a question right or wrong
+type RuleAnswerPayload struct {
+	GameID     string `json:"gameId"`
+	PlayerID   string `json:"playerId"`
+	QuestionID int    `json:"questionId"`
+	Correct    bool   `json:"correct"`
+}
+
+// ExtendTimerPayload adds Seconds to the current question's countdown
+type ExtendTimerPayload struct {
+	GameID  string `json:"gameId"`
+	Seconds int    `json:"seconds"`
+}
+
+// VoidQuestionPayload takes back the points a question gave everyone
+type VoidQuestionPayload struct {
+	GameID     string `json:"gameId"`
+	QuestionID int    `json:"questionId"`
+}
+
+// Direction says which side of the socket sends a message
+type Direction string
+
+const (
+	ServerToClient Direction = "server"
+	ClientToServer Direction = "client"
+)
+
+// CatalogEntry documents a single message in the protocol
+type CatalogEntry struct {
+	Type        string
+	Direction   Direction
+	Description string
+	Payload     interface{}
+}
+
+// Catalog lists every message the server sends or accepts. The JSON Schema
+// contract is generated from it, so new messages must be added here.
+var Catalog = []CatalogEntry{
+	{TypeWelcome, ServerToClient, "Handshake sent when a player or spectator socket is attached", WelcomePayload{}},
+	{TypeAck, ServerToClient, "A client request with the same id was accepted", AckPayload{}},
+	{TypeError, ServerToClient, "A client request with the same id was rejected", ErrorPayload{}},
+	{TypePlayerJoined, ServerToClient, "A player joined the game", PlayersPayload{}},
+	{TypePlayerLeft, ServerToClient, "A player left the game", PlayerLeftPayload{}},
+	{TypeGameState, ServerToClient, "The game lifecycle changed", GameStatePayload{}},
+	{TypeQuestion, ServerToClient, "Questions are open for answers", QuestionPayload{}},
+	{TypePlayerList, ServerToClient, "Admin only: the roster of a game changed", PlayerListPayload{}},
+	{TypePlayerPresence, ServerToClient, "Admin only: a player's presence changed", PlayerPresencePayload{}},
+	{TypePlayerAnswered, ServerToClient, "Admin only: a player submitted an answer", PlayerAnsweredPayload{}},
+	{TypeGameStatus, ServerToClient, "Admin only: snapshot of a game on connect", GameStatusPayload{}},
+	{TypeQuestionLocked, ServerToClient, "The current question stopped accepting answers", QuestionLockedPayload{}},
+	{TypeReveal, ServerToClient, "The correct answer to a question", RevealPayload{}},
+	{TypeLeaderboard, ServerToClient, "Rankings with ties and places moved since the question opened, sent after each reveal", LeaderboardPayload{}},
+	{TypeIntermission, ServerToClient, "A round ended and the game is in the break before the next one", IntermissionPayload{}},
+	{TypeKicked, ServerToClient, "The player was removed from the game by the host", KickedPayload{}},
+	{TypeAlert, ServerToClient, "Admin only: a limit was hit or something needs the host's attention", AlertPayload{}},
+	{TypeSpectators, ServerToClient, "Admin only: how many spectators are following a game", SpectatorsPayload{}},
+	{TypeView, ServerToClient, "Rendered HTML for the player's or presenter's current screen, to swap in by element id", ViewPayload{}},
+	{TypeHello, ClientToServer, "Announces the protocol version the client speaks", HelloPayload{}},
+	{TypeAnswer, ClientToServer, "Submits an answer to a question", AnswerPayload{}},
+	{TypeAuth, ClientToServer, "Admin: authenticates the socket with the admin token", AuthPayload{}},
+	{TypeStartGame, ClientToServer, "Admin: starts a game with the loaded questions", GameCommandPayload{}},
+	{TypePauseGame, ClientToServer, "Admin: pauses a running game", GameCommandPayload{}},
+	{TypeResumeGame, ClientToServer, "Admin: resumes a paused game", GameCommandPayload{}},
+	{TypeNextQuestion, ClientToServer, "Admin: opens the next question", GameCommandPayload{}},
+	{TypeLockQuestion, ClientToServer, "Admin: stops accepting answers to the current question", GameCommandPayload{}},
+	{TypeRevealAnswer, ClientToServer, "Admin: locks and reveals the current question's answer", GameCommandPayload{}},
+	{TypeKickPlayer, ClientToServer, "Admin: removes a player from a game", KickPlayerPayload{}},
+	{TypeBanPlayer, ClientToServer, "Admin: removes a player and bans their player ID and address for the rest of the game", BanPlayerPayload{}},
+	{TypeRenamePlayer, ClientToServer, "Admin: replaces a player's name", RenamePlayerPayload{}},
+	{TypeAdjustScore, ClientToServer, "Admin: adds or deducts points for a player, with a reason", AdjustScorePayload{}},
+	{TypeSkipQuestion, ClientToServer, "Admin: voids the current question and opens the next", GameCommandPayload{}},
+	{TypeReopenQuestion, ClientToServer, "Admin: takes answers to the locked or revealed current question again", GameCommandPayload{}},
+	{TypeExtendTimer, ClientToServer, "Admin: adds seconds to the current question's countdown", ExtendTimerPayload{}},
+	{TypeVoidQuestion, ClientToServer, "Admin: stops a question scoring and takes back its points", VoidQuestionPayload{}},
+	{TypeAcceptAnswer, ClientToServer, "Admin: accepts another answer to a question and rescores everyone who gave it", AcceptAnswerPayload{}},
+	{TypeRuleAnswer, ClientToServer, "Admin: marks one player's answer to a question right or wrong", RuleAnswerPayload{}},
+}
+
+// NewError builds an error reply correlated with the request ID
+func NewError(requestID string, code ErrorCode, message string) Message {
+	return Message{
+		Type:    TypeError,
+		ID:      requestID,
+		Payload: ErrorPayload{Code: code, Message: message},
+	}
+}
+
+// NewAck builds an ack reply correlated with the request ID
+func NewAck(requestID string, requestType string) Message {
+	return Message{
+		Type:    TypeAck,
+		ID:      requestID,
+		Payload: AckPayload{Type: requestType},
+	}
+}
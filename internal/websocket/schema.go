@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"richetechguy/internal/types"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// enums lists the allowed values of string types that act as enumerations
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(ErrorCode("")): {
+		string(ErrBadRequest), string(ErrUnknownType), string(ErrUnsupportedVersion),
+		string(ErrPlayerNotFound), string(ErrInvalidAnswer), string(ErrUnauthorized),
+		string(ErrGameNotFound), string(ErrCommandFailed), string(ErrRateLimited),
+		string(ErrGameFull), string(ErrMessageTooLarge), string(ErrBanned),
+		string(ErrInvalidName), string(ErrGameClosed),
+	},
+	reflect.TypeOf(types.PresenceStatus("")): {
+		string(types.PresenceConnected), string(types.PresenceAway), string(types.PresenceDisconnected),
+	},
+	reflect.TypeOf(types.QuestionType("")): {
+		string(types.SingleChoice), string(types.MultipleChoice),
+	},
+}
+
+// Schema renders the message catalog as a JSON Schema document. It is the
+// contract front-end code should be written against.
+func Schema() ([]byte, error) {
+	defs := map[string]interface{}{}
+	messages := make([]interface{}, 0, len(Catalog))
+
+	for _, entry := range Catalog {
+		name := "message." + entry.Type
+		defs[name] = map[string]interface{}{
+			"description": entry.Description,
+			"x-direction": entry.Direction,
+			"type":        "object",
+			"properties": map[string]interface{}{
+				"type":    map[string]interface{}{"const": entry.Type},
+				"id":      map[string]interface{}{"type": "string", "description": "Correlation ID echoed on ack and error replies"},
+				"payload": schemaFor(reflect.TypeOf(entry.Payload), defs),
+			},
+			"required":             []string{"type", "payload"},
+			"additionalProperties": false,
+		}
+		messages = append(messages, map[string]interface{}{"$ref": "#/$defs/" + name})
+	}
+
+	doc := map[string]interface{}{
+		"$schema":           "https://json-schema.org/draft/2020-12/schema",
+		"$id":               fmt.Sprintf("/static/protocol.v%d.schema.json", ProtocolVersion),
+		"title":             "Party Trivia WebSocket protocol",
+		"x-protocolVersion": ProtocolVersion,
+		"oneOf":             messages,
+		"$defs":             defs,
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// schemaFor describes a Go type, registering named structs under $defs
+func schemaFor(t reflect.Type, defs map[string]interface{}) interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	if values, ok := enums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return structSchema(t, defs)
+		}
+		if _, done := defs[name]; !done {
+			// Reserve the name first so recursive types terminate
+			defs[name] = nil
+			defs[name] = structSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		// interface{} and anything else is unconstrained
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaFor(field.Type, defs)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"richetechguy/internal/game"
+	"richetechguy/internal/metrics"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/ratelimit"
+	"richetechguy/internal/types"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// spectatorConn follows one game's questions, reveals and leaderboards
+// without being a player in it
+type spectatorConn struct {
+	conn   *websocket.Conn
+	gameID string
+	mu     sync.Mutex
+}
+
+func (s *spectatorConn) WriteJSON(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return s.conn.WriteJSON(v)
+}
+
+func (s *spectatorConn) WritePing() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+var (
+	spectatorConnections = make(map[*spectatorConn]bool)
+	spectatorMutex       sync.RWMutex
+)
+
+func init() {
+	metrics.SpectatorConnections.SetSource(func() []metrics.Sample {
+		spectatorMutex.RLock()
+		defer spectatorMutex.RUnlock()
+		return []metrics.Sample{{Value: float64(len(spectatorConnections))}}
+	})
+}
+
+// spectatorMessages are the player broadcasts spectators also receive
+var spectatorMessages = map[string]bool{
+	TypeGameState:      true,
+	TypeQuestion:       true,
+	TypeQuestionLocked: true,
+	TypeReveal:         true,
+	TypeLeaderboard:    true,
+	TypeIntermission:   true,
+}
+
+// serveSpectator handles /ws/game?role=spectator&gameId=<id>. Spectators get
+// the game's questions, reveals and leaderboards but never join Players, so
+// they can't answer and don't count towards the player limit. Anything they
+// send is ignored.
+func serveSpectator(gameManager *game.GameManager, limits *ratelimit.Limits, w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		middleware.Logger(r.Context()).Warn("websocket upgrade failed", "err", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(limits.Config.MaxMessageBytes)
+
+	gameState, err := gameManager.AddSpectator(r.URL.Query().Get("gameId"), limits.ClientIP(r))
+	if err != nil {
+		conn.WriteJSON(joinError(err))
+		return
+	}
+	middleware.Annotate(r.Context(), "game_id", gameState.ID)
+	spectator := &spectatorConn{conn: conn, gameID: gameState.ID}
+
+	spectatorMutex.Lock()
+	spectatorConnections[spectator] = true
+	spectatorMutex.Unlock()
+	announceSpectators(gameState)
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		spectatorMutex.Lock()
+		delete(spectatorConnections, spectator)
+		spectatorMutex.Unlock()
+		gameManager.RemoveSpectator(gameState)
+		announceSpectators(gameState)
+	}()
+
+	spectator.WriteJSON(Message{
+		Type:    TypeWelcome,
+		Payload: WelcomePayload{ProtocolVersion: ProtocolVersion, GameID: gameState.ID, Spectator: true},
+	})
+	resync(gameState, spectator)
+	spectator.WriteJSON(Message{
+		Type:    TypeLeaderboard,
+		Payload: LeaderboardPayload{GameID: gameState.ID, Rankings: game.BuildLeaderboard(gameState).Rankings},
+	})
+
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	go func() {
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := spectator.WritePing(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+	}
+}
+
+// deliverToSpectators writes the player broadcasts spectators follow to the
+// spectators of a game connected to this instance
+func deliverToSpectators(gameState *types.GameState, msg interface{}) {
+	if !spectatorMessages[messageType(msg)] {
+		return
+	}
+	spectatorMutex.RLock()
+	var spectators []*spectatorConn
+	for spectator := range spectatorConnections {
+		if spectator.gameID == gameState.ID {
+			spectators = append(spectators, spectator)
+		}
+	}
+	spectatorMutex.RUnlock()
+
+	for _, spectator := range spectators {
+		if err := spectator.WriteJSON(msg); err != nil {
+			metrics.DroppedMessages.Inc("spectators")
+			slog.Warn("broadcast to spectator failed", "game_id", gameState.ID, "err", err)
+			// The read loop notices the close and unregisters the spectator
+			spectator.conn.Close()
+		}
+	}
+}
+
+// messageType reads the type of a broadcast, which arrives from the broker
+// still encoded
+func messageType(msg interface{}) string {
+	switch m := msg.(type) {
+	case Message:
+		return m.Type
+	case json.RawMessage:
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(m, &envelope)
+		return envelope.Type
+	}
+	return ""
+}
+
+// announceSpectators tells every dashboard how many spectators a game has now
+func announceSpectators(gameState *types.GameState) {
+	BroadcastToAdmins(Message{
+		Type:    TypeSpectators,
+		Payload: SpectatorsPayload{GameID: gameState.ID, Count: game.SpectatorCount(gameState)},
+	})
+}
@@ -0,0 +1,134 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"richetechguy/internal/game"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/ratelimit"
+	"richetechguy/internal/types"
+	"time"
+)
+
+// HandleEventStream is the Server-Sent Events fallback for /ws/game. It carries
+// the same messages for networks that strip WebSocket upgrades; clients send
+// their requests to HandlePlayerMessage instead.
+func HandleEventStream(gameManager *game.GameManager, limits *ratelimit.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowConnection(w, r, limits) {
+			return
+		}
+
+		transport, err := newSSETransport(w)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		activeGame, player, anonymous, err := joinGame(gameManager, r, limits.ClientIP(r))
+		if err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, types.ErrGameFull) {
+				status = http.StatusConflict
+			}
+			if errors.Is(err, types.ErrBanned) {
+				status = http.StatusForbidden
+			}
+			if errors.Is(err, types.ErrGameStarted) || errors.Is(err, types.ErrGameEnded) {
+				status = http.StatusConflict
+			}
+			var nameErr *game.NameError
+			if errors.As(err, &nameErr) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		middleware.Annotate(r.Context(), "game_id", activeGame.ID, "player_id", player.ID)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		// Stop nginx-style proxies from buffering the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		transport.flusher.Flush()
+
+		connectPlayer(activeGame, player, transport)
+
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				disconnectPlayer(activeGame, player, transport, anonymous)
+				return
+			case <-transport.done:
+				// Kicked or replaced by a newer connection
+				disconnectPlayer(activeGame, player, transport, anonymous)
+				return
+			case <-ticker.C:
+				// There is no pong on an event stream, so a write that
+				// succeeds is the best sign of life we get
+				if err := player.WritePing(); err != nil {
+					disconnectPlayer(activeGame, player, transport, anonymous)
+					return
+				}
+				if player.Touch() {
+					broadcastPresence(activeGame, player)
+				}
+			}
+		}
+	}
+}
+
+// HandlePlayerMessage accepts a single protocol message over a plain POST, for
+// clients on the event stream fallback. The reply is the ack or error that a
+// socket client would have received.
+func HandlePlayerMessage(gameManager *game.GameManager, limits *ratelimit.Limits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		r.Body = http.MaxBytesReader(w, r.Body, limits.Config.MaxMessageBytes)
+
+		activeGame, player := findPlayer(gameManager, r.URL.Query().Get("gameId"), r.URL.Query().Get("playerId"))
+		if player == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(NewError("", ErrPlayerNotFound, "player not found"))
+			return
+		}
+		middleware.Annotate(r.Context(), "game_id", activeGame.ID, "player_id", player.ID)
+
+		if !limits.Messages.Allow(player.ID) {
+			AlertAdmins(ErrRateLimited, fmt.Sprintf("%s is sending messages too quickly", player.Name),
+				activeGame.ID, player.ID)
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(NewError("", ErrRateLimited, "slow down"))
+			return
+		}
+
+		var msg incomingMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(NewError("", ErrMessageTooLarge, err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(NewError("", ErrBadRequest, fmt.Sprintf("invalid message: %v", err)))
+			return
+		}
+
+		if player.Touch() {
+			broadcastPresence(activeGame, player)
+		}
+		reply := handlePlayerMessage(msg, player, activeGame, limits)
+		if reply.Type == TypeError {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(reply)
+	}
+}
@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsTransport delivers messages to a player over a WebSocket
+type wsTransport struct {
+	conn *websocket.Conn
+}
+
+func (t *wsTransport) WriteJSON(v interface{}) error {
+	t.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return t.conn.WriteJSON(v)
+}
+
+func (t *wsTransport) Ping() error {
+	return t.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+func (t *wsTransport) Close(code int, reason string) error {
+	t.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	return t.conn.Close()
+}
+
+// sseTransport delivers messages to a player over a Server-Sent Events stream.
+// It is only valid while the HTTP handler that created it is still running.
+type sseTransport struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newSSETransport(w http.ResponseWriter) (*sseTransport, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported by this connection")
+	}
+	return &sseTransport{w: w, flusher: flusher, done: make(chan struct{})}, nil
+}
+
+func (t *sseTransport) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.write(fmt.Sprintf("data: %s\n\n", data))
+}
+
+// Ping writes an SSE comment line, which clients ignore but proxies see as traffic
+func (t *sseTransport) Ping() error {
+	return t.write(": ping\n\n")
+}
+
+// Close tells the client why the stream is ending and releases the handler
+func (t *sseTransport) Close(code int, reason string) error {
+	t.once.Do(func() {
+		if code != 0 && reason != "" {
+			t.write(fmt.Sprintf("event: close\ndata: %s\n\n", reason))
+		}
+		close(t.done)
+	})
+	return nil
+}
+
+func (t *sseTransport) write(frame string) error {
+	select {
+	case <-t.done:
+		return fmt.Errorf("event stream is closed")
+	default:
+	}
+	if _, err := fmt.Fprint(t.w, frame); err != nil {
+		return err
+	}
+	t.flusher.Flush()
+	return nil
+}
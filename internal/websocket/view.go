@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"richetechguy/internal/broker"
+	"richetechguy/internal/game"
+	"richetechguy/internal/metrics"
+	"richetechguy/internal/template"
+	"richetechguy/internal/types"
+)
+
+// PushViews re-renders the screen of every player and presenter of a game and
+// sends it to them, on whichever instance they are connected. Call it after
+// anything that changes what players see.
+func PushViews(gameState *types.GameState) {
+	metrics.Broadcasts.Inc("players")
+	if manager == nil {
+		deliverViews(gameState)
+		return
+	}
+
+	// Views differ per player, so instances render their own connections
+	payload, _ := json.Marshal(playersEvent{GameID: gameState.ID, Views: true})
+	if err := manager.Broker.Publish(broker.TopicPlayers, payload); err != nil {
+		slog.Error("publishing view refresh failed", "game_id", gameState.ID, "err", err)
+	}
+}
+
+// pushLobby refreshes screens when the roster changes, which only the lobby shows
+func pushLobby(gameState *types.GameState) {
+	gameState.Mu.RLock()
+	inLobby := !gameState.IsActive && gameState.EndTime.IsZero()
+	gameState.Mu.RUnlock()
+	if inLobby {
+		PushViews(gameState)
+	}
+}
+
+// deliverViews renders and sends the views of the players and presenters
+// connected to this instance
+func deliverViews(gameState *types.GameState) {
+	gameState.Mu.RLock()
+	players := make([]*types.Player, 0, len(gameState.Players))
+	for _, player := range gameState.Players {
+		players = append(players, player)
+	}
+	gameState.Mu.RUnlock()
+
+	for _, player := range players {
+		if !player.IsConnected() {
+			continue
+		}
+		if err := sendView(gameState, player); err != nil {
+			metrics.DroppedMessages.Inc("players")
+			slog.Warn("sending view to player failed", "game_id", gameState.ID, "player_id", player.ID, "err", err)
+			player.CloseConnection()
+		}
+	}
+	deliverPresenterViews(gameState)
+}
+
+// sendView renders one player's current screen and sends it to them
+func sendView(gameState *types.GameState, player *types.Player) error {
+	html, err := RenderView(gameState, player.ID)
+	if err != nil {
+		return err
+	}
+	return player.WriteJSON(Message{Type: TypeView, Payload: ViewPayload{HTML: html}})
+}
+
+// RenderView renders a player's screen as an out-of-band swap fragment
+func RenderView(gameState *types.GameState, playerID string) (string, error) {
+	var buf bytes.Buffer
+	view := game.BuildPlayerView(gameState, playerID)
+	if err := template.PlayerView(view, true).Render(context.Background(), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
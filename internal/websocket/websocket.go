@@ -1,20 +1,70 @@
 package websocket
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"richetechguy/internal/game"
+	"richetechguy/internal/metrics"
+	"richetechguy/internal/middleware"
+	"richetechguy/internal/ratelimit"
 	"richetechguy/internal/types"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// writeWait is how long a single write may take before the peer is considered gone
+	writeWait = 10 * time.Second
+	// pingPeriod is how often the server pings each connection
+	pingPeriod = 10 * time.Second
+	// awayAfter marks a player as away once nothing has been heard from them for this long
+	awayAfter = 25 * time.Second
+	// pongWait is the read deadline; a connection silent for this long is dropped
+	pongWait = 45 * time.Second
+)
+
+// adminConn wraps an admin socket so pings and broadcasts never write concurrently
+type adminConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+	// authenticated is set by the handshake or an auth message; broadcasts
+	// skip sockets that haven't authenticated
+	authenticated atomic.Bool
+}
+
+func (a *adminConn) WriteJSON(v interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return a.conn.WriteJSON(v)
+}
+
+func (a *adminConn) WritePing() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
 var (
-	adminConnections = make(map[*websocket.Conn]bool)
+	adminConnections = make(map[*adminConn]bool)
 	adminMutex       sync.RWMutex
 )
+
+func init() {
+	metrics.AdminConnections.SetSource(func() []metrics.Sample {
+		adminMutex.RLock()
+		defer adminMutex.RUnlock()
+		return []metrics.Sample{{Value: float64(len(adminConnections))}}
+	})
+}
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -23,218 +73,516 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-type Message struct {
-	Type    string      `json:"type"`
-	Payload interface{} `json:"payload"`
+// adminUpgrader leaves CheckOrigin unset, so browsers can only open the admin
+// socket from this site's own pages. Clients that send no Origin, like
+// scripts, are still let through to authenticate.
+var adminUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
 }
 
-func HandleWebSocket(gameManager *game.GameManager) http.HandlerFunc {
+func HandleWebSocket(gameManager *game.GameManager, limits *ratelimit.Limits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowConnection(w, r, limits) {
+			return
+		}
+		if r.URL.Query().Get("role") == "spectator" {
+			serveSpectator(gameManager, limits, w, r)
+			return
+		}
+
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			fmt.Printf("WebSocket upgrade error: %v\n", err)
+			middleware.Logger(r.Context()).Warn("websocket upgrade failed", "err", err)
 			return
 		}
 		defer conn.Close()
+		conn.SetReadLimit(limits.Config.MaxMessageBytes)
 
-		// Get player name from query parameters
-		playerName := r.URL.Query().Get("name")
-		if playerName == "" {
-			playerName = fmt.Sprintf("Player_%d", time.Now().UnixNano())
+		activeGame, player, anonymous, err := joinGame(gameManager, r, limits.ClientIP(r))
+		if err != nil {
+			conn.WriteJSON(joinError(err))
+			return
 		}
+		middleware.Annotate(r.Context(), "game_id", activeGame.ID, "player_id", player.ID)
+		transport := &wsTransport{conn: conn}
+		connectPlayer(activeGame, player, transport)
 
-		// Create a new player ID
-		playerID := fmt.Sprintf("player_%d", time.Now().UnixNano())
-
-		// Find an available game or create a new one
-		var activeGame *types.GameState
-		games := gameManager.GetAllGames()
-		for _, g := range games {
-			if !g.IsActive {
-				activeGame = g
-				break
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+			if player.Touch() {
+				broadcastPresence(activeGame, player)
 			}
-		}
+			return nil
+		})
 
-		if activeGame == nil {
-			gameM, err := gameManager.CreateGame("Rookie of the Year")
-			if err != nil {
-				http.Error(w, "Error creating game", http.StatusInternalServerError)
-				return
-			}
-			activeGame, _ = gameManager.GetGame(gameM.ID)
-		}
-
-		// Add player to game
-		player := &types.Player{
-			ID:      playerID,
-			Name:    playerName,
-			Score:   0,
-			Answers: make(map[int]string),
-			WSConn:  conn,
-			GameID:  activeGame.ID,
-		}
-
-		activeGame.Players[player.ID] = player
-
-		// Broadcast player joined message
-		// broadcastMessage := Message{
-		// 	Type: "playerJoined",
-		// 	Payload: map[string]interface{}{
-		// 		"players": activeGame.Players,
-		// 	},
-		// }
-		// broadcastToPlayers(activeGame, broadcastMessage)
-		// After adding player to game
-		// activeGame.Players[player.ID] = player
-
-		// Broadcast to other players
-		broadcastMessage := Message{
-			Type: "playerJoined",
-			Payload: map[string]interface{}{
-				"players": activeGame.Players,
-			},
-		}
-		broadcastToPlayers(activeGame, broadcastMessage)
-		// Notify admins
-
-		adminMessage := Message{
-			Type: "playerList",
-			Payload: map[string]interface{}{
-				"gameId":   activeGame.ID,
-				"players":  activeGame.Players,
-				"isActive": activeGame.IsActive, // Add this line
-			},
-		}
+		done := make(chan struct{})
+		defer close(done)
+		go pingPlayer(activeGame, player, done)
 
-		broadcastToAdmins(adminMessage)
 		// Handle incoming messages
 		for {
-			var msg Message
-			err := conn.ReadJSON(&msg)
+			_, data, err := conn.ReadMessage()
 			if err != nil {
-				fmt.Printf("WebSocket read error: %v\n", err)
-				delete(activeGame.Players, player.ID)
-				broadcastPlayerLeft(activeGame, player)
+				middleware.Logger(r.Context()).Info("player socket closed", "err", err)
+				if errors.Is(err, websocket.ErrReadLimit) {
+					AlertAdmins(ErrMessageTooLarge, fmt.Sprintf("%s sent an oversized message and was disconnected", player.Name),
+						activeGame.ID, player.ID)
+				}
+				disconnectPlayer(activeGame, player, transport, anonymous)
 				break
 			}
 
-			handlePlayerMessage(msg, player, activeGame)
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+			if player.Touch() {
+				broadcastPresence(activeGame, player)
+			}
+
+			if !limits.Messages.Allow(player.ID) {
+				AlertAdmins(ErrRateLimited, fmt.Sprintf("%s is sending messages too quickly", player.Name),
+					activeGame.ID, player.ID)
+				player.WriteJSON(NewError("", ErrRateLimited, "slow down"))
+				continue
+			}
+
+			var msg incomingMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				player.WriteJSON(NewError("", ErrBadRequest, "message is not valid JSON"))
+				continue
+			}
+			player.WriteJSON(handlePlayerMessage(msg, player, activeGame, limits))
 		}
 	}
 }
 
-func broadcastToPlayers(gameState *types.GameState, msg Message) {
-	for _, player := range gameState.Players {
-		if player.WSConn != nil {
-			if err := player.WSConn.WriteJSON(msg); err != nil {
-				fmt.Printf("Error broadcasting to player %s: %v\n", player.ID, err)
-				player.CloseConnection()
-				delete(gameState.Players, player.ID)
+// allowConnection applies the per-IP connection limit, answering 429 when it is hit
+func allowConnection(w http.ResponseWriter, r *http.Request, limits *ratelimit.Limits) bool {
+	ip := limits.ClientIP(r)
+	if limits.Joins.Allow("connect:" + ip) {
+		return true
+	}
+	AlertAdmins(ErrRateLimited, "Too many connection attempts from one address", "", ip)
+	ratelimit.Reject(w, limits.Joins, "Too many connection attempts, try again shortly")
+	return false
+}
+
+// joinError turns a joinGame failure into the error reply for the client
+func joinError(err error) Message {
+	if errors.Is(err, types.ErrGameFull) {
+		return NewError("", ErrGameFull, err.Error())
+	}
+	if errors.Is(err, types.ErrBanned) {
+		return NewError("", ErrBanned, err.Error())
+	}
+	if errors.Is(err, types.ErrGameStarted) || errors.Is(err, types.ErrGameEnded) {
+		return NewError("", ErrGameClosed, err.Error())
+	}
+	var nameErr *game.NameError
+	if errors.As(err, &nameErr) {
+		return NewError("", ErrInvalidName, err.Error())
+	}
+	return NewError("", ErrGameNotFound, err.Error())
+}
+
+// joinGame finds the player a new connection from ip belongs to. Players who
+// joined through the lobby form pass gameId and playerId; anyone else joins
+// the game named by gameId, or the first one that hasn't started, under the
+// same ban, name and late join rules as the lobby form.
+func joinGame(gameManager *game.GameManager, r *http.Request, ip string) (*types.GameState, *types.Player, bool, error) {
+	// Reattach to a player that already joined through the lobby form
+	gameID, playerID := r.URL.Query().Get("gameId"), r.URL.Query().Get("playerId")
+	activeGame, player := findPlayer(gameManager, gameID, playerID)
+	if player != nil {
+		return activeGame, player, false, nil
+	}
+	if g, err := gameManager.GetGame(gameID); err == nil && playerID != "" && g.IsBanned(playerID, "") {
+		return nil, nil, false, types.ErrBanned
+	}
+
+	// Join the game the client asked for, or else the first one that hasn't
+	// started, creating one if there is none
+	if gameID != "" {
+		g, err := gameManager.GetGame(gameID)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		activeGame = g
+	} else {
+		for _, g := range gameManager.GetAllGames() {
+			if !g.IsActive && g.EndTime.IsZero() {
+				activeGame = g
+				break
 			}
 		}
 	}
+	if activeGame == nil {
+		gameM, err := gameManager.CreateGame(game.DefaultGameName)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("error creating game: %w", err)
+		}
+		activeGame = gameM
+	}
+
+	// Sockets that don't give a name get a generated one, and are marked so
+	// player lists can leave them out
+	playerName := r.URL.Query().Get("name")
+	var err error
+	if playerName == "" {
+		playerID, err = gameManager.AddGuest(activeGame.ID, ip)
+	} else {
+		playerID, err = gameManager.AddPlayer(activeGame.ID, playerName, ip)
+	}
+	if errors.Is(err, types.ErrGameFull) {
+		AlertAdmins(ErrGameFull, fmt.Sprintf("%s could not join, the game is full", playerName), activeGame.ID, ip)
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+	_, player = findPlayer(gameManager, activeGame.ID, playerID)
+	if player == nil {
+		return nil, nil, false, fmt.Errorf("player not found")
+	}
+
+	return activeGame, player, true, nil
 }
-func BroadcastToPlayers(gameState *types.GameState, msg Message) {
-	for _, player := range gameState.Players {
-		if player.WSConn != nil {
-			if err := player.WSConn.WriteJSON(msg); err != nil {
-				fmt.Printf("Error broadcasting to player %s: %v\n", player.ID, err)
-				player.CloseConnection()
-				delete(gameState.Players, player.ID)
+
+// connectPlayer attaches a transport to the player and announces them
+func connectPlayer(activeGame *types.GameState, player *types.Player, transport types.Transport) {
+	player.SetConnection(transport)
+
+	// Tell the client who it is and which protocol we speak
+	player.WriteJSON(Message{
+		Type: TypeWelcome,
+		Payload: WelcomePayload{
+			ProtocolVersion: ProtocolVersion,
+			GameID:          activeGame.ID,
+			PlayerID:        player.ID,
+			Name:            player.Name,
+		},
+	})
+	// Resync the screen, which may have moved on while the player was away
+	if err := sendView(activeGame, player); err != nil {
+		slog.Warn("sending view to player failed", "game_id", activeGame.ID, "player_id", player.ID, "err", err)
+	}
+	resync(activeGame, player)
+
+	// Broadcast to other players
+	broadcastMessage := Message{
+		Type:    TypePlayerJoined,
+		Payload: PlayersPayload{Players: publicPlayers(activeGame)},
+	}
+	BroadcastToPlayers(activeGame, broadcastMessage)
+	pushLobby(activeGame)
+
+	// Notify admins
+	adminMessage := Message{
+		Type: TypePlayerList,
+		Payload: PlayerListPayload{
+			GameID:   activeGame.ID,
+			Players:  copyPlayers(activeGame),
+			IsActive: isActive(activeGame),
+		},
+	}
+	BroadcastToAdmins(adminMessage)
+	broadcastPresence(activeGame, player)
+}
+
+// jsonWriter is a socket that messages can be written to
+type jsonWriter interface {
+	WriteJSON(v interface{}) error
+}
+
+// resync replays the messages a client missed by arriving mid-game, so API
+// clients that joined late, reconnected or are spectating land on the
+// current question
+func resync(gameState *types.GameState, client jsonWriter) {
+	gameState.Mu.RLock()
+	if !gameState.IsActive {
+		gameState.Mu.RUnlock()
+		return
+	}
+	var messages []Message
+	if gameState.IsPaused {
+		messages = append(messages, Message{
+			Type:    TypeGameState,
+			Payload: GameStatePayload{State: "paused", Message: "The host paused the game"},
+		})
+	}
+	payload := QuestionPayload{
+		State:     "active",
+		Message:   "Questions has started!",
+		Questions: PlayerQuestions(gameState.Questions),
+		GameID:    gameState.ID,
+	}
+	if question := gameState.CurrentQuestion; question != nil && !gameState.SelfPaced {
+		payload.Message = fmt.Sprintf("Question %d", gameState.Round)
+		payload.Current = NewPlayerQuestion(question)
+		messages = append(messages, Message{Type: TypeQuestion, Payload: payload})
+		if gameState.IsLocked {
+			messages = append(messages, Message{
+				Type:    TypeQuestionLocked,
+				Payload: QuestionLockedPayload{GameID: gameState.ID, QuestionID: question.ID},
+			})
+		}
+		if gameState.IsRevealed {
+			messages = append(messages, Message{
+				Type:    TypeReveal,
+				Payload: RevealPayload{GameID: gameState.ID, QuestionID: question.ID, Correct: question.Correct},
+			})
+		}
+	} else if gameState.SelfPaced {
+		messages = append(messages, Message{Type: TypeQuestion, Payload: payload})
+	}
+	gameState.Mu.RUnlock()
+
+	// BuildIntermission takes the lock itself
+	if intermission := game.BuildIntermission(gameState); intermission != nil {
+		messages = append(messages, Message{
+			Type:    TypeIntermission,
+			Payload: intermissionPayload(gameState.ID, intermission),
+		})
+	}
+
+	for _, msg := range messages {
+		if err := client.WriteJSON(msg); err != nil {
+			slog.Warn("resyncing client failed", "game_id", gameState.ID, "err", err)
+			return
+		}
+	}
+}
+
+// disconnectPlayer records that a transport went away. Anonymous players are
+// removed since nothing can reattach to them.
+func disconnectPlayer(activeGame *types.GameState, player *types.Player, transport types.Transport, anonymous bool) {
+	if !player.DetachConnection(transport) && player.IsConnected() {
+		// A newer connection took over this player
+		return
+	}
+	if anonymous {
+		activeGame.Mu.Lock()
+		delete(activeGame.Players, player.ID)
+		activeGame.Mu.Unlock()
+	}
+	broadcastPlayerLeft(activeGame, player)
+	broadcastPresence(activeGame, player)
+}
+
+// findPlayer looks up a player created by the lobby form so their socket can be attached to them
+func findPlayer(gameManager *game.GameManager, gameID string, playerID string) (*types.GameState, *types.Player) {
+	if gameID == "" || playerID == "" {
+		return nil, nil
+	}
+	gameState, err := gameManager.GetGame(gameID)
+	if err != nil {
+		return nil, nil
+	}
+	gameState.Mu.RLock()
+	defer gameState.Mu.RUnlock()
+
+	player, exists := gameState.Players[playerID]
+	if !exists {
+		return nil, nil
+	}
+	return gameState, player
+}
+
+// pingPlayer keeps the connection alive and marks the player away when pongs stop arriving
+func pingPlayer(gameState *types.GameState, player *types.Player, done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := player.WritePing(); err != nil {
+				return
+			}
+			status, lastSeen := player.Presence()
+			if status == types.PresenceConnected && time.Since(lastSeen) > awayAfter {
+				if player.SetPresence(types.PresenceAway) {
+					broadcastPresence(gameState, player)
+				}
 			}
 		}
 	}
 }
+
+// broadcastPresence tells the admin dashboard, and other instances, that a player's presence changed
+func broadcastPresence(gameState *types.GameState, player *types.Player) {
+	syncGame(gameState)
+	status, lastSeen := player.Presence()
+	active := isActive(gameState)
+	BroadcastToAdmins(Message{
+		Type: TypePlayerPresence,
+		Payload: PlayerPresencePayload{
+			GameID:   gameState.ID,
+			PlayerID: player.ID,
+			Status:   status,
+			LastSeen: lastSeen,
+			IsActive: active,
+		},
+	})
+}
+
 func broadcastPlayerLeft(gameState *types.GameState, player *types.Player) {
 	msg := Message{
-		Type: "playerLeft",
-		Payload: map[string]interface{}{
-			"playerID": player.ID,
-			"players":  gameState.Players,
+		Type: TypePlayerLeft,
+		Payload: PlayerLeftPayload{
+			PlayerID: player.ID,
+			Players:  publicPlayers(gameState),
 		},
 	}
-	broadcastToPlayers(gameState, msg)
+	BroadcastToPlayers(gameState, msg)
+	pushLobby(gameState)
 }
 
-func handlePlayerMessage(msg Message, player *types.Player, gameState *types.GameState) {
+// handlePlayerMessage applies a client request and returns the ack or error to send back
+func handlePlayerMessage(msg incomingMessage, player *types.Player, gameState *types.GameState, limits *ratelimit.Limits) Message {
 	switch msg.Type {
-	case "answer":
-		if payload, ok := msg.Payload.(map[string]interface{}); ok {
-			if answer, ok := payload["answer"].(string); ok {
-				gameState.SubmitAnswer(player.ID, answer)
+	case TypeHello:
+		var payload HelloPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid hello payload")
+		}
+		if payload.ProtocolVersion != ProtocolVersion {
+			return NewError(msg.ID, ErrUnsupportedVersion,
+				fmt.Sprintf("server speaks protocol version %d", ProtocolVersion))
+		}
+		return NewAck(msg.ID, msg.Type)
+	case TypeAnswer:
+		if !limits.Answers.Allow(gameState.ID + ":" + player.ID) {
+			AlertAdmins(ErrRateLimited, fmt.Sprintf("%s is submitting answers too quickly", player.Name),
+				gameState.ID, player.ID)
+			metrics.Answers.Inc("rate_limited")
+			return NewError(msg.ID, ErrRateLimited, "too many answers, slow down")
+		}
+		var payload AnswerPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return NewError(msg.ID, ErrBadRequest, "invalid answer payload")
+		}
+		if payload.Answer == "" {
+			return NewError(msg.ID, ErrInvalidAnswer, "answer is required")
+		}
+		if payload.QuestionID == 0 {
+			// No question ID means the answer is for the game's current question
+			if err := gameState.SubmitAnswer(player.ID, payload.Answer); err != nil {
+				metrics.Answers.Inc("rejected")
+				return NewError(msg.ID, ErrInvalidAnswer, err.Error())
 			}
+		} else if err := gameState.RecordAnswer(player, payload.QuestionID, payload.Answer); err != nil {
+			metrics.Answers.Inc("rejected")
+			return NewError(msg.ID, ErrInvalidAnswer, err.Error())
 		}
+		metrics.Answers.Inc("accepted")
+		syncGame(gameState)
+		if err := sendView(gameState, player); err != nil {
+			slog.Warn("sending view to player failed", "game_id", gameState.ID, "player_id", player.ID, "err", err)
+		}
+		PushPresenters(gameState)
+
+		gameState.Mu.RLock()
+		score := player.Score
+		gameState.Mu.RUnlock()
+		BroadcastToAdmins(Message{
+			Type: TypePlayerAnswered,
+			Payload: PlayerAnsweredPayload{
+				GameID:     gameState.ID,
+				PlayerID:   player.ID,
+				QuestionID: payload.QuestionID,
+				Score:      score,
+			},
+		})
+		return NewAck(msg.ID, msg.Type)
+	default:
+		return NewError(msg.ID, ErrUnknownType, fmt.Sprintf("unknown message type %q", msg.Type))
 	}
 }
-func HandleAdminWebSocket(gameManager *game.GameManager) http.HandlerFunc {
+func HandleAdminWebSocket(gameManager *game.GameManager, qm *game.QuestionManager, limits *ratelimit.Limits) http.HandlerFunc {
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		slog.Warn("ADMIN_TOKEN is not set, admin socket commands will be refused")
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
+		conn, err := adminUpgrader.Upgrade(w, r, nil)
 		if err != nil {
-			http.Error(w, "Could not upgrade connection", http.StatusInternalServerError)
+			// The upgrader has already answered, e.g. 403 for another site's page
+			middleware.Logger(r.Context()).Warn("admin websocket upgrade failed", "err", err)
 			return
 		}
+		conn.SetReadLimit(limits.Config.MaxMessageBytes)
+		admin := &adminConn{conn: conn}
+		// The dashboard's socket carries the browser's admin login
+		admin.authenticated.Store(middleware.IsAdmin(r, adminToken))
+
 		// Add connection to admin connections
 		adminMutex.Lock()
-		adminConnections[conn] = true
+		adminConnections[admin] = true
 		adminMutex.Unlock()
 
+		done := make(chan struct{})
 		defer func() {
+			close(done)
 			adminMutex.Lock()
-			delete(adminConnections, conn)
+			delete(adminConnections, admin)
 			adminMutex.Unlock()
 			conn.Close()
 		}()
-		// defer conn.Close()
-
-		// Send initial game state
-		games := gameManager.GetAllGames()
-		for gameID, game := range games {
-			msg := Message{
-				Type: "gameStatus",
-				Payload: map[string]interface{}{
-					"gameId": gameID,
-					"status": game.GetGameStatus(),
-				},
-			}
-			conn.WriteJSON(msg)
+
+		if admin.authenticated.Load() {
+			sendGameStatus(admin, gameManager)
 		}
 
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			conn.SetReadDeadline(time.Now().Add(pongWait))
+			return nil
+		})
+		go func() {
+			ticker := time.NewTicker(pingPeriod)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := admin.WritePing(); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
 		// Keep connection alive and handle any admin commands
 		for {
-			var msg Message
-			err := conn.ReadJSON(&msg)
+			_, data, err := conn.ReadMessage()
 			if err != nil {
 				break
 			}
-			fmt.Println("Received message:", msg)
-
-			// Handle admin messages if needed
-		}
-	}
-}
-func broadcastToAdmins(msg Message) {
+			conn.SetReadDeadline(time.Now().Add(pongWait))
 
-	adminMutex.RLock()
-	defer adminMutex.RUnlock()
-
-	for conn := range adminConnections {
-		if err := conn.WriteJSON(msg); err != nil {
-			fmt.Printf("Error broadcasting to admin: %v\n", err)
-			conn.Close()
-			delete(adminConnections, conn)
+			var msg incomingMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				admin.WriteJSON(NewError("", ErrBadRequest, "message is not valid JSON"))
+				continue
+			}
+			admin.WriteJSON(handleAdminMessage(msg, admin, adminToken, gameManager, qm))
 		}
 	}
 }
-func BroadcastToAdmins(msg Message) {
-	adminMutex.RLock()
-	defer adminMutex.RUnlock()
 
-	for conn := range adminConnections {
-		if err := conn.WriteJSON(msg); err != nil {
-			fmt.Printf("Error broadcasting to admin: %v\n", err)
-			conn.Close()
-			delete(adminConnections, conn)
-		}
+// sendGameStatus sends a newly authenticated admin socket the state of every game
+func sendGameStatus(admin *adminConn, gameManager *game.GameManager) {
+	for gameID, game := range gameManager.GetAllGames() {
+		admin.WriteJSON(Message{
+			Type: TypeGameStatus,
+			Payload: GameStatusPayload{
+				GameID: gameID,
+				Status: game.GetGameStatus(),
+			},
+		})
 	}
 }
@@ -1,18 +1,32 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"richetechguy/internal/api"
+	"richetechguy/internal/certificate"
 	"richetechguy/internal/game"
 	"richetechguy/internal/generate"
+	"richetechguy/internal/health"
+	"richetechguy/internal/metrics"
 	"richetechguy/internal/middleware"
+	"richetechguy/internal/notify"
+	"richetechguy/internal/ratelimit"
 	"richetechguy/internal/template"
 	"richetechguy/internal/types"
 	"richetechguy/internal/view"
+	"richetechguy/internal/webhook"
 	"richetechguy/internal/websocket"
 	"strconv"
+	"strings"
 	"time"
 
 	"richetechguy/internal/admin"
@@ -21,8 +35,16 @@ import (
 	"github.com/joho/godotenv"
 )
 
-func handleJoinGame(gm *game.GameManager) http.HandlerFunc {
+func handleJoinGame(gm *game.GameManager, limits *ratelimit.Limits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ip := limits.ClientIP(r)
+		if !limits.Joins.Allow("join:" + ip) {
+			websocket.AlertAdmins(websocket.ErrRateLimited, "Too many join attempts from one address", "", ip)
+			ratelimit.Reject(w, limits.Joins, "Too many join attempts, try again shortly")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limits.Config.MaxMessageBytes)
+
 		name := r.FormValue("name")
 		gameID := r.FormValue("gameId")
 
@@ -32,15 +54,36 @@ func handleJoinGame(gm *game.GameManager) http.HandlerFunc {
 		}
 
 		// Add player to game
-		playerID, err := gm.AddPlayer(gameID, name)
+		playerID, err := gm.AddPlayer(gameID, name, ip)
+		if errors.Is(err, types.ErrGameFull) {
+			websocket.AlertAdmins(websocket.ErrGameFull, fmt.Sprintf("%s could not join, the game is full", name), gameID, ip)
+			http.Error(w, "This game is full", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, types.ErrBanned) {
+			http.Error(w, "You have been banned from this game", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, types.ErrGameStarted) || errors.Is(err, types.ErrGameEnded) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		var nameErr *game.NameError
+		if errors.As(err, &nameErr) {
+			// Sent back as a form so htmx swaps it in with the suggestions
+			template.JoinForm(gm, gameID, name, nameErr).Render(r.Context(), w)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		fmt.Printf("Player ID added: %s and name: %s\n", playerID, name)
+		middleware.Annotate(r.Context(), "game_id", gameID, "player_id", playerID)
+		middleware.Logger(r.Context()).Info("player joined", "name", name)
 
 		// Render game lobby with player info
-		template.GameLobby(name, playerID).Render(r.Context(), w)
+		g, _ := gm.GetGame(gameID)
+		template.GameLobby(game.BuildPlayerView(g, playerID)).Render(r.Context(), w)
 	}
 }
 
@@ -65,7 +108,7 @@ func handleSubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Process the data (for now, we'll just print it)
-	fmt.Printf("Received submission - Name: %s\n", name)
+	middleware.Logger(r.Context()).Info("received submission", "name", name)
 	// Send a response
 	w.Write([]byte("Form submitted successfully"))
 }
@@ -84,7 +127,7 @@ func handleAdmin(gm *game.GameManager) http.HandlerFunc {
 func handleCreateGame(gm *game.GameManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		//NOTE: THIS is a placeholder for the game creation logic
-		gameID, err := gm.CreateGame("Rookie of the Year")
+		gameID, err := gm.CreateGame(game.DefaultGameName)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -97,6 +140,7 @@ func handleCreateGame(gm *game.GameManager) http.HandlerFunc {
 func handleStartGame(gm *game.GameManager, qm *game.QuestionManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		gameID := r.FormValue("gameID")
+		middleware.Annotate(r.Context(), "game_id", gameID)
 		if err := gm.StartGame(gameID, qm); err != nil {
 			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -105,28 +149,7 @@ func handleStartGame(gm *game.GameManager, qm *game.QuestionManager) http.Handle
 
 		// Get the game state
 		game, _ := gm.GetGame(gameID)
-
-		// Broadcast to players
-		playerMessage := websocket.Message{
-			Type: "gameState",
-			Payload: map[string]interface{}{
-				"state":   "active",
-				"message": "Game has started!",
-				"players": game.Players,
-			},
-		}
-		websocket.BroadcastToPlayers(game, playerMessage)
-
-		// Broadcast to admins
-		adminMessage := websocket.Message{
-			Type: "playerList",
-			Payload: map[string]interface{}{
-				"gameId":   gameID,
-				"players":  game.Players,
-				"isActive": true,
-			},
-		}
-		websocket.BroadcastToAdmins(adminMessage)
+		websocket.AnnounceGameStarted(game)
 
 		w.Header().Set("HX-Trigger", "gameStarted")
 		fmt.Fprintf(w, "Game started")
@@ -136,7 +159,7 @@ func handleStartGame(gm *game.GameManager, qm *game.QuestionManager) http.Handle
 func handleSelectGame(gm *game.GameManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		gameID := r.FormValue("gameID")
-		fmt.Println(gameID)
+		middleware.Annotate(r.Context(), "game_id", gameID)
 		gameState, err := gm.SelectGame(gameID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -149,7 +172,15 @@ func handleSelectGame(gm *game.GameManager) http.HandlerFunc {
 func handleEndGame(gm *game.GameManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		gameID := r.FormValue("gameID")
-		gm.EndGame(gameID)
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		if err := gm.EndGame(gameID); err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if g, err := gm.GetGame(gameID); err == nil {
+			websocket.PushViews(g)
+		}
 		w.Header().Set("HX-Trigger", "gameEnded")
 		fmt.Fprintf(w, "Game ended")
 	}
@@ -185,6 +216,198 @@ func handleAddQuestion(qm *game.QuestionManager) http.HandlerFunc {
 		admin.QuestionList(qm.GetQuestions()).Render(r.Context(), w)
 	}
 }
+
+func handleResultsPage(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		g, err := gm.FindGame(gameID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		admin.StandingsPage(game.BuildResults(g)).Render(r.Context(), w)
+	}
+}
+
+// handlePresenter serves the big-screen view of a game to anyone holding its presenter token
+func handlePresenter(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		token := r.URL.Query().Get("token")
+		g, err := gm.GetGame(gameID)
+		if err != nil || !game.CheckPresenterToken(g, token) {
+			http.Error(w, "Invalid presenter link", http.StatusForbidden)
+			return
+		}
+		template.PresenterPage(game.BuildPresenterView(g), token).Render(r.Context(), w)
+	}
+}
+
+// handleResultsExport downloads a game's results as csv or json
+func handleResultsExport(gm *game.GameManager, format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		g, err := gm.FindGame(gameID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		results := game.BuildResults(g)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-results.%s"`, gameID, format))
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			err = results.WriteCSV(w)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			err = results.WriteJSON(w)
+		}
+		if err != nil {
+			slog.Error("writing results failed", "game_id", gameID, "err", err)
+		}
+	}
+}
+
+// handleCertificate downloads one player's certificate as svg or pdf
+func handleCertificate(gm *game.GameManager, certificates *certificate.Renderer, format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, playerID := r.PathValue("id"), r.PathValue("player")
+		middleware.Annotate(r.Context(), "game_id", gameID, "player_id", playerID)
+		g, err := gm.FindGame(gameID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		results := game.BuildResults(g)
+		for _, player := range results.Players {
+			if player.PlayerID != playerID {
+				continue
+			}
+			c := certificate.New(results, player)
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": certificate.Filename(c) + "." + format}))
+			if format == "pdf" {
+				w.Header().Set("Content-Type", "application/pdf")
+				err = certificates.PDF(w, c)
+			} else {
+				w.Header().Set("Content-Type", "image/svg+xml")
+				err = certificates.SVG(w, c)
+			}
+			if err != nil {
+				slog.Error("rendering certificate failed", "game_id", gameID, "player_id", playerID, "err", err)
+			}
+			return
+		}
+		http.Error(w, "Player not found", http.StatusNotFound)
+	}
+}
+
+// handleCertificateZip downloads the certificates of everyone on the podium
+func handleCertificateZip(gm *game.GameManager, certificates *certificate.Renderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		g, err := gm.FindGame(gameID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		// Render into memory first so a template error can still become a 500
+		var buf bytes.Buffer
+		if err := certificates.Zip(&buf, game.BuildResults(g)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-certificates.zip"`, gameID))
+		w.Write(buf.Bytes())
+	}
+}
+
+// deliveryLogSize is how many webhook delivery attempts the dashboard shows
+const deliveryLogSize = 50
+
+// renderWebhooks shows the webhooks panel; created is an endpoint just added,
+// whose secret is shown once
+func renderWebhooks(gm *game.GameManager, w http.ResponseWriter, r *http.Request, created *webhook.Endpoint) {
+	endpoints, err := gm.Db.ListWebhooks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	deliveries, err := gm.Db.RecentDeliveries(deliveryLogSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	admin.Webhooks(endpoints, deliveries, created).Render(r.Context(), w)
+}
+
+func handleWebhooks(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderWebhooks(gm, w, r, nil)
+	}
+}
+
+func handleAddWebhook(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Error parsing form data", http.StatusBadRequest)
+			return
+		}
+		target, err := url.Parse(r.FormValue("url"))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			http.Error(w, "Webhook URL must be an http or https URL", http.StatusBadRequest)
+			return
+		}
+		secret := r.FormValue("secret")
+		if secret == "" {
+			secret = webhook.NewSecret()
+		}
+
+		endpoint := webhook.Endpoint{
+			URL:    target.String(),
+			Secret: secret,
+			Events: r.Form["events"],
+		}
+		if _, err := gm.Db.AddWebhook(endpoint); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		renderWebhooks(gm, w, r, &endpoint)
+	}
+}
+
+func handleDeleteWebhook(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+			return
+		}
+		if err := gm.Db.DeleteWebhook(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		renderWebhooks(gm, w, r, nil)
+	}
+}
+
+func handleWebhookDeliveries(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deliveries, err := gm.Db.RecentDeliveries(deliveryLogSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		admin.DeliveryLog(deliveries).Render(r.Context(), w)
+	}
+}
+
 func handleGameStatus(gm *game.GameManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		gameID := r.FormValue("gameID")
@@ -193,134 +416,386 @@ func handleGameStatus(gm *game.GameManager) http.HandlerFunc {
 	}
 }
 
+func handleUpdateBlocklist(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		words := strings.FieldsFunc(r.FormValue("words"), func(r rune) bool { return r == '\n' || r == '\r' })
+		if err := gm.UpdateBlocklist(words); err != nil {
+			slog.Error("saving name blocklist failed", "err", err)
+			w.Header().Set("HX-Trigger", `{"showMessage": "Saving the blocklist failed"}`)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		admin.NameBlocklist(gm.Blocklist, "Blocklist saved. It applies to new names from now on.").Render(r.Context(), w)
+	}
+}
+
+func handleTheme(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		game, _ := gm.GetGame(r.FormValue("gameID"))
+		admin.ThemeForm(game, "").Render(r.Context(), w)
+	}
+}
+
+func handleUpdateTheme(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Error parsing form data", http.StatusBadRequest)
+			return
+		}
+		gameState, err := gm.SetTheme(gameID, types.Theme{
+			Title:          r.FormValue("title"),
+			Subtitle:       r.FormValue("subtitle"),
+			LogoURL:        r.FormValue("logoUrl"),
+			BackgroundURL:  r.FormValue("backgroundUrl"),
+			PrimaryColor:   r.FormValue("primaryColor"),
+			SecondaryColor: r.FormValue("secondaryColor"),
+			LinkURL:        r.FormValue("linkUrl"),
+			LinkText:       r.FormValue("linkText"),
+		})
+		if err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		admin.ThemeForm(gameState, "Theme saved. Players see it the next time their page loads.").Render(r.Context(), w)
+	}
+}
+
+// auditLogSize is how many host actions the dashboard lists
+const auditLogSize = 20
+
+func handleHostControls(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, err := gm.GetGame(r.FormValue("gameID"))
+		if err != nil {
+			admin.HostControls(nil, nil).Render(r.Context(), w)
+			return
+		}
+		renderHostControls(gm, g, w, r)
+	}
+}
+
+func handleHostAction(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		action := game.HostAction(r.PathValue("action"))
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		value := 0
+		if raw := r.FormValue("value"); raw != "" {
+			var err error
+			if value, err = strconv.Atoi(raw); err != nil {
+				http.Error(w, "value must be a number", http.StatusBadRequest)
+				return
+			}
+		}
+		g, err := websocket.RunHostAction(gm, gameID, action, value)
+		if err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		renderHostControls(gm, g, w, r)
+	}
+}
+
+// handleLateJoin changes whether players can join a game after it started
+func handleLateJoin(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		policy, err := game.ParseLateJoinPolicy(r.FormValue("lateJoin"))
+		if err == nil {
+			var g *types.GameState
+			if g, err = gm.SetLateJoinPolicy(gameID, policy); err == nil {
+				renderHostControls(gm, g, w, r)
+				return
+			}
+		}
+		w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func renderHostControls(gm *game.GameManager, g *types.GameState, w http.ResponseWriter, r *http.Request) {
+	audit, err := gm.AuditLog(g.ID, auditLogSize)
+	if err != nil {
+		slog.Error("loading audit log failed", "game_id", g.ID, "err", err)
+	}
+	view := game.BuildHostView(g)
+	admin.HostControls(&view, audit).Render(r.Context(), w)
+}
+
+func handleScoreBreakdown(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g, err := gm.GetGame(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		renderScoreBreakdown(g, r.PathValue("player"), w, r)
+	}
+}
+
+func handleAdjustScore(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, playerID := r.PathValue("id"), r.PathValue("player")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		points, err := strconv.Atoi(r.FormValue("points"))
+		if err != nil {
+			http.Error(w, "points must be a number", http.StatusBadRequest)
+			return
+		}
+		g, _, err := gm.AdjustScore(gameID, playerID, points, r.FormValue("reason"))
+		if err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		websocket.AnnounceScores(g)
+		renderScoreBreakdown(g, playerID, w, r)
+	}
+}
+
+func handleRuleAnswer(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, playerID := r.PathValue("id"), r.PathValue("player")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		questionID, err := strconv.Atoi(r.FormValue("question"))
+		if err != nil {
+			http.Error(w, "question must be a number", http.StatusBadRequest)
+			return
+		}
+		g, err := gm.RuleAnswer(gameID, playerID, questionID, r.FormValue("correct") == "true")
+		if err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		websocket.AnnounceScores(g)
+		renderScoreBreakdown(g, playerID, w, r)
+	}
+}
+
+func handleAcceptAnswer(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID := r.PathValue("id")
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		questionID, err := strconv.Atoi(r.PathValue("question"))
+		if err != nil {
+			http.Error(w, "question must be a number", http.StatusBadRequest)
+			return
+		}
+		g, _, err := gm.AcceptAnswer(gameID, questionID, r.FormValue("answer"))
+		if err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		websocket.AnnounceScores(g)
+		renderScoreBreakdown(g, r.FormValue("player"), w, r)
+	}
+}
+
+// renderScoreBreakdown shows a player's breakdown, or the empty card when the
+// player is unknown
+func renderScoreBreakdown(g *types.GameState, playerID string, w http.ResponseWriter, r *http.Request) {
+	breakdown, err := game.BuildBreakdown(g, playerID)
+	if err != nil {
+		admin.ScoreBreakdown(g.ID, nil).Render(r.Context(), w)
+		return
+	}
+	admin.ScoreBreakdown(g.ID, &breakdown).Render(r.Context(), w)
+}
+
+// handleRemovePlayer kicks a player, or bans them when ban is set. The
+// dashboard asks for the reason with hx-prompt.
+func handleRemovePlayer(gm *game.GameManager, ban bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, playerID := r.PathValue("id"), r.PathValue("player")
+		middleware.Annotate(r.Context(), "game_id", gameID, "player_id", playerID)
+		reason := strings.TrimSpace(r.Header.Get("HX-Prompt"))
+		remove := gm.KickPlayer
+		if ban {
+			remove = gm.BanPlayer
+		}
+		g, player, err := remove(gameID, playerID, reason)
+		if player == nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("saving game after removing a player failed", "game_id", gameID, "err", err)
+		}
+		websocket.AnnounceRemoval(g, player, reason)
+		admin.PlayerList(g).Render(r.Context(), w)
+	}
+}
+
+func handleRenamePlayer(gm *game.GameManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, playerID := r.PathValue("id"), r.PathValue("player")
+		middleware.Annotate(r.Context(), "game_id", gameID, "player_id", playerID)
+		g, err := gm.RenamePlayer(gameID, playerID, r.Header.Get("HX-Prompt"))
+		if err != nil {
+			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		websocket.AnnounceScores(g)
+		admin.PlayerList(g).Render(r.Context(), w)
+	}
+}
+
 func handlePlayerList(gm *game.GameManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		gameID := r.FormValue("gameID")
 		game, _ := gm.GetGame(gameID)
 		if game != nil {
-			admin.PlayerList(game.Players, gameID).Render(r.Context(), w)
+			admin.PlayerList(game).Render(r.Context(), w)
 		}
 	}
 }
-func handleStartQuestions(gm *game.GameManager, qm *game.QuestionManager) http.HandlerFunc {
-
+func handleStartQuestions(gm *game.GameManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		gameID := r.FormValue("gameID")
-		// if err := gm.StartGame(gameID, qm); err != nil {
-		// 	w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
-		// 	http.Error(w, err.Error(), http.StatusBadRequest)
-		// 	return
-		// }
-
-		// Get the game state
-		game, err := gm.GetGame(gameID)
+		middleware.Annotate(r.Context(), "game_id", gameID)
+		game, err := gm.StartSelfPaced(gameID)
 		if err != nil {
 			w.Header().Set("HX-Trigger", fmt.Sprintf(`{"showMessage": "%s"}`, err))
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		// Broadcast to players
-		// {
-		//   id: string;
-		//   text: string;
-		//   options: string[];
-		// }
-		questions := qm.GetQuestions()
-		playerMessage := websocket.Message{
-			Type: "question",
-			Payload: map[string]interface{}{
-				"state":     "active",
-				"message":   "Questions has started!",
-				"questions": questions,
-				"gameId":    gameID,
+		// API clients still get the whole deck; browsers get their first question card
+		websocket.BroadcastToPlayers(game, websocket.Message{
+			Type: websocket.TypeQuestion,
+			Payload: websocket.QuestionPayload{
+				State:     "active",
+				Message:   "Questions has started!",
+				Questions: websocket.PlayerQuestions(game.Questions),
+				GameID:    gameID,
 			},
-		}
-		websocket.BroadcastToPlayers(game, playerMessage)
-
-		// // Broadcast to admins
-		// adminMessage := websocket.Message{
-		// 	Type: "playerList",
-		// 	Payload: map[string]interface{}{
-		// 		"gameId":   gameID,
-		// 		"players":  game.Players,
-		// 		"isActive": true,
-		// 	},
-		// }
-		// websocket.BroadcastToAdmins(adminMessage)
-
-		// w.Header().Set("HX-Trigger", "gameStarted")
+		})
+		websocket.PushViews(game)
 		fmt.Fprintf(w, "Questions started")
 	}
 }
-func handleAnswerSubmission(gm *game.GameManager, qm *game.QuestionManager) http.HandlerFunc {
+
+// handleAnswerSubmission takes an answer from the question card and returns
+// the player's next screen in its place
+func handleAnswerSubmission(gm *game.GameManager, limits *ratelimit.Limits) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, limits.Config.MaxMessageBytes)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
 		gameID := r.FormValue("gameID")
 		questionID := r.FormValue("questionID")
-		answer := r.FormValue("answer")
-		playerID := r.FormValue("playerID") // You'll need to pass this from the session
+		// Multiple-choice questions send one answer field per ticked option
+		answer := strings.Join(r.Form["answer"], ",")
+		playerID := r.FormValue("playerID")
+		middleware.Annotate(r.Context(), "game_id", gameID, "player_id", playerID)
 
-		game, err := gm.GetGame(gameID)
+		g, err := gm.GetGame(gameID)
 		if err != nil {
 			http.Error(w, "Game not found", http.StatusBadRequest)
 			return
 		}
 
-		player, exists := game.Players[playerID]
+		g.Mu.RLock()
+		player, exists := g.Players[playerID]
+		g.Mu.RUnlock()
 		if !exists {
 			http.Error(w, "Player not found", http.StatusBadRequest)
 			return
 		}
 
-		qID, _ := strconv.Atoi(questionID)
-		player.SubmitAnswer(qID, answer)
-
-		// Broadcast answer submission to admin
-		adminMsg := websocket.Message{
-			Type: "playerAnswered",
-			Payload: map[string]interface{}{
-				"gameId":     gameID,
-				"playerId":   playerID,
-				"questionId": qID,
-				"score":      player.Score,
-			},
+		if !limits.Answers.Allow(gameID + ":" + playerID) {
+			websocket.AlertAdmins(websocket.ErrRateLimited, fmt.Sprintf("%s is submitting answers too quickly", player.Name),
+				gameID, playerID)
+			metrics.Answers.Inc("rate_limited")
+			ratelimit.Reject(w, limits.Answers, "Too many answers, slow down")
+			return
 		}
-		websocket.BroadcastToAdmins(adminMsg)
 
-		questions := qm.GetQuestions()
-		playerMessage := websocket.Message{
-			Type: "question",
-			Payload: map[string]interface{}{
-				"state":     "active",
-				"message":   "On the next question!",
-				"questions": questions,
-				"gameId":    gameID,
-			},
+		qID, _ := strconv.Atoi(questionID)
+		if answer == "" {
+			metrics.Answers.Inc("rejected")
+		} else if err := g.RecordAnswer(player, qID, answer); err != nil {
+			// The screen is stale, e.g. the host locked the question; show the current one
+			metrics.Answers.Inc("rejected")
+			slog.Info("answer rejected", "game_id", gameID, "player_id", playerID, "err", err)
+		} else {
+			metrics.Answers.Inc("accepted")
+			gm.Sync(g)
+			websocket.PushPresenters(g)
+			g.Mu.RLock()
+			score := player.Score
+			g.Mu.RUnlock()
+			websocket.BroadcastToAdmins(websocket.Message{
+				Type: websocket.TypePlayerAnswered,
+				Payload: websocket.PlayerAnsweredPayload{
+					GameID:     gameID,
+					PlayerID:   playerID,
+					QuestionID: qID,
+					Score:      score,
+				},
+			})
 		}
-		websocket.BroadcastToPlayers(game, playerMessage)
 
-		// Return updated question view or confirmation
-		w.Write([]byte("Answer submitted!"))
+		template.PlayerView(game.BuildPlayerView(g, playerID), false).Render(r.Context(), w)
 	}
 }
 func main() {
+	_ = godotenv.Load()
+	slog.SetDefault(middleware.NewLogger(os.Stdout, os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL")))
+
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
 
+// serve runs the web server; it's what the binary does with no subcommand
+func serve() {
 	err := generate.GenerateMain()
 	if err != nil {
 		panic(err)
 	}
 
-	_ = godotenv.Load()
 	mux := http.NewServeMux()
 
-	dbURL := os.Getenv("TURSO_DATABASE_URL")
-	authToken := os.Getenv("TURSO_AUTH_TOKEN")
+	limitConfig, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+	limits := ratelimit.NewLimits(limitConfig)
 
 	questionManager := game.NewQuestionManager()
-	gameManager, err := game.NewGameManager(dbURL, authToken)
+	certificates, err := certificate.NewRenderer(os.Getenv("CERTIFICATE_TEMPLATE"))
+	if err != nil {
+		log.Fatalf("Invalid certificate template: %v", err)
+	}
+	gameManager, closeGames, err := openGameManager()
 	if err != nil {
 		log.Fatalf("Failed to initialize game manager: %v", err)
 	}
+	defer closeGames()
+	gameManager.MaxPlayersPerGame = limitConfig.MaxPlayersPerGame
+	if gameManager.NameRules, err = game.NameRulesFromEnv(); err != nil {
+		log.Fatalf("Invalid name rules: %v", err)
+	}
+	if err := gameManager.LoadBlocklist(os.Getenv("NAME_BLOCKLIST_FILE")); err != nil {
+		log.Fatalf("Failed to load the name blocklist: %v", err)
+	}
+	gameManager.Webhooks = webhook.NewDispatcher(gameManager.Db, webhook.Options{})
+	defer gameManager.Webhooks.Close()
+	websocket.UseBroker(gameManager)
+	go websocket.RunClock(gameManager)
 	// Add periodic state saving
 	go func() {
 		ticker := time.NewTicker(5 * time.Minute)
@@ -329,7 +804,7 @@ func main() {
 
 			for _, game := range gameManager.Games {
 				if err := gameManager.Db.SaveGame(game); err != nil {
-					log.Printf("Error saving game state: %v", err)
+					slog.Error("saving game state failed", "game_id", game.ID, "err", err)
 				}
 			}
 		}
@@ -342,38 +817,111 @@ func main() {
 
 	// Load existing questions
 	if err := questionManager.LoadQuestions(); err != nil {
-		log.Printf("Error loading questions: %v", err)
+		slog.Error("loading questions failed", "err", err)
 	}
 	//TODO: modify to bd db instead of questions.json
 	// questionManager.LoadQuestions()
 	// fmt.Println(questionManager.GetQuestions())
 
-	// Admin routes
-	mux.HandleFunc("GET /admin", handleAdmin(gameManager))
-	mux.HandleFunc("POST /admin/game/create", handleCreateGame(gameManager))
-	mux.HandleFunc("POST /admin/game/start", handleStartGame(gameManager, questionManager))
-	mux.HandleFunc("POST /admin/game/end", handleEndGame(gameManager))
-	mux.HandleFunc("POST /admin/game/clear", handleClearGames(gameManager))
-	mux.HandleFunc("POST /admin/game/select", handleSelectGame(gameManager))
-	mux.HandleFunc("POST /admin/questions/add", handleAddQuestion(questionManager))
+	// Admin routes, all behind the admin token
+	requireAdmin := middleware.RequireAdmin(os.Getenv("ADMIN_TOKEN"))
+	admin := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, requireAdmin(handler))
+	}
+	admin("GET /admin", handleAdmin(gameManager))
+	admin("POST /admin/game/create", handleCreateGame(gameManager))
+	admin("POST /admin/game/start", handleStartGame(gameManager, questionManager))
+	admin("POST /admin/game/end", handleEndGame(gameManager))
+	admin("POST /admin/game/clear", handleClearGames(gameManager))
+	admin("POST /admin/game/select", handleSelectGame(gameManager))
+	admin("POST /admin/questions/add", handleAddQuestion(questionManager))
+	admin("GET /admin/games/{id}/results", handleResultsPage(gameManager))
+	admin("GET /admin/games/{id}/results.csv", handleResultsExport(gameManager, "csv"))
+	admin("GET /admin/games/{id}/results.json", handleResultsExport(gameManager, "json"))
+	admin("GET /admin/games/{id}/players/{player}/certificate.svg", handleCertificate(gameManager, certificates, "svg"))
+	admin("GET /admin/games/{id}/players/{player}/certificate.pdf", handleCertificate(gameManager, certificates, "pdf"))
+	admin("GET /admin/games/{id}/certificates.zip", handleCertificateZip(gameManager, certificates))
+	admin("GET /admin/webhooks", handleWebhooks(gameManager))
+	admin("POST /admin/webhooks", handleAddWebhook(gameManager))
+	admin("POST /admin/webhooks/delete", handleDeleteWebhook(gameManager))
+	admin("GET /admin/webhooks/deliveries", handleWebhookDeliveries(gameManager))
 	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
-		middleware.Chain(w, r, template.JoinGame(gameManager))
+		selected := r.URL.Query().Get("game")
+		if selected == "" {
+			selected = gameManager.GetFirstGameID()
+		}
+		g, _ := gameManager.GetGame(selected)
+		middleware.Chain(w, r, template.JoinGame(gameManager, game.ResolveTheme(g), selected))
+	})
+
+	admin("POST /admin/game/startQuestions", handleStartQuestions(gameManager))
+	mux.HandleFunc("POST /game/submit-answer", handleAnswerSubmission(gameManager, limits))
+
+	admin("GET /admin/game/status", handleGameStatus(gameManager))
+	admin("GET /admin/game/players", handlePlayerList(gameManager))
+	admin("GET /admin/game/host", handleHostControls(gameManager))
+	admin("POST /admin/games/{id}/host/{action}", handleHostAction(gameManager))
+	admin("POST /admin/games/{id}/late-join", handleLateJoin(gameManager))
+	admin("GET /admin/games/{id}/players/{player}/score", handleScoreBreakdown(gameManager))
+	admin("POST /admin/games/{id}/players/{player}/adjustments", handleAdjustScore(gameManager))
+	admin("POST /admin/games/{id}/players/{player}/rulings", handleRuleAnswer(gameManager))
+	admin("POST /admin/games/{id}/questions/{question}/accepted", handleAcceptAnswer(gameManager))
+	admin("POST /admin/games/{id}/players/{player}/kick", handleRemovePlayer(gameManager, false))
+	admin("POST /admin/games/{id}/players/{player}/ban", handleRemovePlayer(gameManager, true))
+	admin("POST /admin/games/{id}/players/{player}/rename", handleRenamePlayer(gameManager))
+	admin("GET /admin/game/theme", handleTheme(gameManager))
+	admin("POST /admin/names/blocklist", handleUpdateBlocklist(gameManager))
+	admin("POST /admin/games/{id}/theme", handleUpdateTheme(gameManager))
+
+	mux.HandleFunc("GET /ws/admin", websocket.HandleAdminWebSocket(gameManager, questionManager, limits))
+	mux.HandleFunc("POST /joinGame", handleJoinGame(gameManager, limits))
+	mux.HandleFunc("GET /ws/game", websocket.HandleWebSocket(gameManager, limits))
+	mux.HandleFunc("GET /present/{id}", handlePresenter(gameManager))
+	mux.HandleFunc("GET /ws/present", websocket.HandlePresenterWebSocket(gameManager, limits))
+	// Fallback for networks that strip WebSocket upgrades
+	mux.HandleFunc("GET /sse/game", websocket.HandleEventStream(gameManager, limits))
+	mux.HandleFunc("POST /game/message", websocket.HandlePlayerMessage(gameManager, limits))
+
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+
+	// JSON API for the mobile app and scripts, next to the htmx admin
+	api.Register(mux, api.Deps{
+		Games:      gameManager,
+		Questions:  questionManager,
+		Limits:     limits,
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
 	})
 
-	mux.HandleFunc("POST /admin/game/startQuestions", handleStartQuestions(gameManager, questionManager))
-	mux.HandleFunc("POST /game/submit-answer", handleAnswerSubmission(gameManager, questionManager))
+	readiness := health.NewChecker(5*time.Second,
+		health.Check{Name: "database", Run: gameManager.Db.Ping},
+		health.Check{Name: "migrations", Run: gameManager.Db.CheckMigrations},
+	)
+	mux.HandleFunc("GET /healthz", health.HandleLive)
+	mux.HandleFunc("GET /readyz", health.HandleReady(readiness))
 
-	mux.HandleFunc("GET /admin/game/status", handleGameStatus(gameManager))
-	mux.HandleFunc("GET /admin/game/players", handlePlayerList(gameManager))
+	// Posts incidents and daily status reports to chat when NOTIFY_*_WEBHOOK is set
+	notifyConfig, err := notify.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid notifier configuration: %v", err)
+	}
+	if notifyConfig.Enabled() {
+		monitor := notify.NewMonitor(notifyConfig, func(ctx context.Context) error {
+			return readiness.Run(ctx).Err()
+		})
+		go monitor.Run(context.Background())
+	}
 
-	mux.HandleFunc("GET /ws/admin", websocket.HandleAdminWebSocket(gameManager))
-	mux.HandleFunc("POST /joinGame", handleJoinGame(gameManager))
-	mux.HandleFunc("GET /ws/game", websocket.HandleWebSocket(gameManager))
+	handler := middleware.Stack(mux,
+		middleware.RequestIDs,
+		middleware.AccessLog,
+		middleware.Metrics(mux),
+		middleware.Recover,
+	)
 
-	fmt.Printf("server is running on  http://localhost:%s\n", os.Getenv("PORT"))
-	err = http.ListenAndServe(":"+os.Getenv("PORT"), mux)
+	slog.Info("server is running", "url", "http://localhost:"+os.Getenv("PORT"))
+	err = http.ListenAndServe(":"+os.Getenv("PORT"), handler)
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("server stopped", "err", err)
 	}
 
 }
@@ -42,3 +42,93 @@ CREATE TABLE IF NOT EXISTS game_results (
     FOREIGN KEY (player_id) REFERENCES players(id)
 );
 
+
+-- Events table (shared pub/sub log when running several instances)
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    topic TEXT NOT NULL,
+    origin TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Webhook endpoints registered from the admin dashboard
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per webhook delivery attempt
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Saved roster of each game, written with the game by SaveGame
+CREATE TABLE IF NOT EXISTS game_players (
+    game_id TEXT NOT NULL,
+    player_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    score INTEGER NOT NULL DEFAULT 0,
+    answers JSON,
+    last_seen DATETIME,
+    PRIMARY KEY (game_id, player_id)
+);
+
+-- Milliseconds each player took to answer, by question ID
+ALTER TABLE game_players ADD COLUMN response_times JSON;
+
+-- Token that lets a big screen follow a game without admin credentials
+ALTER TABLE games ADD COLUMN presenter_token TEXT NOT NULL DEFAULT '';
+
+-- Branding shown on the game's join, lobby, player and presenter pages
+ALTER TABLE games ADD COLUMN theme JSON NOT NULL DEFAULT '{}';
+
+-- Questions the host voided, which score nothing for anyone
+ALTER TABLE games ADD COLUMN voided JSON NOT NULL DEFAULT '[]';
+
+-- The host's manual score changes for each player, with reasons
+ALTER TABLE game_players ADD COLUMN adjustments JSON;
+
+-- The host's calls on individual answers, by question ID
+ALTER TABLE game_players ADD COLUMN rulings JSON;
+
+-- Players the host banned, by player ID and address, for the game's lifetime
+ALTER TABLE games ADD COLUMN bans JSON NOT NULL DEFAULT '[]';
+
+-- Players given a generated name by a socket that joined without one
+ALTER TABLE game_players ADD COLUMN anonymous BOOLEAN NOT NULL DEFAULT 0;
+
+-- Where each player joined from, so bans by address survive a restart
+ALTER TABLE game_players ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+
+-- Whether players can join once the game started: disallow, zero or median
+ALTER TABLE games ADD COLUMN late_join TEXT NOT NULL DEFAULT 'disallow';
+
+-- Named rounds splitting a game's questions, with their points, timer and scoring
+ALTER TABLE games ADD COLUMN rounds JSON NOT NULL DEFAULT '[]';
+
+-- Host overrides during a game: pauses, skips, re-opens, extensions and voids
+CREATE TABLE IF NOT EXISTS game_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Words kept out of player names, edited on the dashboard
+CREATE TABLE IF NOT EXISTS name_blocklist (
+    word TEXT PRIMARY KEY
+);
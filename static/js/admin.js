@@ -24,6 +24,37 @@ function updatePlayerList(playerListHtml) {
 		playerList.innerHTML = playerListHtml;
 	}
 }
+// How many alerts stay on screen at once, and for how long
+const maxToasts = 5;
+const toastLifetime = 6000;
+
+/**
+ * Shows an alert in a corner of the dashboard without blocking it. Alerts
+ * can arrive in bursts, so older ones give way to newer ones.
+ * @param {string} message
+ */
+function showToast(message) {
+	let toasts = document.getElementById('adminToasts');
+	if (!toasts) {
+		toasts = document.createElement('div');
+		toasts.id = 'adminToasts';
+		toasts.className = 'fixed bottom-4 right-4 z-50 flex flex-col gap-2 max-w-sm';
+		toasts.setAttribute('role', 'status');
+		document.body.appendChild(toasts);
+	}
+
+	const toast = document.createElement('div');
+	toast.className = 'bg-yellow-100 border border-yellow-400 text-yellow-900 px-4 py-2 rounded shadow cursor-pointer';
+	toast.textContent = message;
+	toast.title = 'Dismiss';
+	toast.addEventListener('click', () => toast.remove());
+	toasts.appendChild(toast);
+	while (toasts.children.length > maxToasts) {
+		toasts.firstElementChild.remove();
+	}
+	setTimeout(() => toast.remove(), toastLifetime);
+}
+
 document.addEventListener('htmx:afterOnLoad', function() {
 	if (window.gameSocket) {
 		return;
@@ -42,9 +73,36 @@ document.addEventListener('htmx:afterOnLoad', function() {
 			case 'gameStatus':
 				htmx.ajax('GET', '/admin/game/status', { target: '#gameStatus' });
 				break;
+			case 'playerPresence':
+				// Refresh the list so hosts see who is connected, away or gone,
+				// in the lobby as well as during the game
+				htmx.ajax('GET', `/admin/game/players?gameID=${data.payload.gameId}`, {
+					target: '#playerList',
+					swap: 'innerHTML'
+				});
+				break;
+			case 'leaderboard':
+				// Re-rank the list after a reveal
+				htmx.ajax('GET', `/admin/game/players?gameID=${data.payload.gameId}`, {
+					target: '#playerList',
+					swap: 'innerHTML'
+				});
+				break;
+			case 'spectators': {
+				const count = document.getElementById(`spectatorCount-${data.payload.gameId}`);
+				if (count) {
+					count.textContent = data.payload.count;
+				}
+				break;
+			}
 			case 'playerAnswered':
 				console.log('Player answered:', data.payload);
 				break;
+			case 'alert':
+				// Rate limits, full games and oversized messages
+				console.warn('Alert:', data.payload);
+				showToast(data.payload.message);
+				break;
 			case 'playerList':
 				// Only update player list if game is active
 				if (data.payload.isActive) {
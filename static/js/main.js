@@ -1,56 +1,42 @@
 //@ts-check
 
 /**
- * @typedef {Object} Player
- * @property {string} id - Player's unique identifier
- * @property {string} name - Player's display name
- * @property {number} score - Player's current scoremain
- * @property {Object.<number, string>} answers - Player's answers to questions
- */
-/**
- * @typedef {Object} Question
- * @property {string} id - Question identifier
- * @property {string} text - Question text
- * @property {string[]} options - Available answer options
- * @property {string} type - Question type ('single' or 'multiple')
- */
-/**
- * @typedef {Object} GameData
- * @property {string} gameId - Game identifier
- * @property {string} status - Current game status
- */
-
-/**
- * @typedef {Object} PlayerJoinedMessage
- * @property {'playerJoined'} type
- * @property {Object.<string, Player>} payload
+ * @typedef {Object} ViewMessage
+ * @property {'view'} type
+ * @property {Object} payload
+ * @property {string} payload.html - Elements to swap in by id, like htmx's hx-swap-oob
  */
 
 /**
- * @typedef {Object} GameStartedMessage
- * @property {'gameStarted'} type
- * @property {GameData} payload
+ * @typedef {Object} KickedMessage
+ * @property {'kicked'} type
+ * @property {Object} payload
+ * @property {string} payload.reason
  */
 
 /**
- * @typedef {Object} QuestionMessage
- * @property {'question'} type
+ * @typedef {Object} WelcomeMessage
+ * @property {'welcome'} type
  * @property {Object} payload
- * @property {string} payload.state
- * @property {string} payload.message
+ * @property {number} payload.protocolVersion
  * @property {string} payload.gameId
- * @property {Array<Question>} payload.questions
+ * @property {string} payload.playerId
+ * @property {string} payload.name
  */
 /**
- * @typedef {Object} GameStateMessage
- * @property {'gameState'} type
+ * @typedef {Object} ReplyMessage
+ * @property {'ack' | 'error'} type
+ * @property {string} [id] - Correlation ID of the request this answers
  * @property {Object} payload
- * @property {string} payload.state
- * @property {string} payload.message
- * @property {Object.<string, Player>} payload.players
+ * @property {string} [payload.code]
+ * @property {string} [payload.message]
  */
 
-/** @typedef {PlayerJoinedMessage | GameStartedMessage | QuestionMessage | GameStateMessage} GameMessage */
+/** @typedef {ViewMessage | KickedMessage | WelcomeMessage | ReplyMessage} GameMessage */
+
+// Must match websocket.ProtocolVersion; see /static/protocol.v1.schema.json for the full catalog
+const PROTOCOL_VERSION = 1;
+let nextRequestID = 1;
 
 
 
@@ -58,31 +44,53 @@
  * Initializes the game client
  */
 function main() {
-	window.currentQuestion = 0;
 	// Check if we're on the game lobby page
-	const playersListElement = document.getElementById('players-list');
-	if (playersListElement) {
+	if (document.getElementById('player-view')) {
 		connectGameWebSocket();
+		startCountdowns();
+		document.body.addEventListener('htmx:afterSwap', startCountdowns);
+	}
+}
+
+/**
+ * Builds the query string that attaches a connection to the player created by the join form
+ * @returns {URLSearchParams}
+ */
+function playerParams() {
+	const params = new URLSearchParams();
+	if (window.playerID && window.gameID) {
+		params.set('playerId', window.playerID);
+		params.set('gameId', window.gameID);
 	}
+	return params;
 }
 
+// Sockets that close this many times without ever opening are assumed to be blocked
+const MAX_FAILED_SOCKETS = 2;
+let failedSockets = 0;
+
 /**
- * Establishes WebSocket connection to the game server
+ * Establishes WebSocket connection to the game server, falling back to
+ * Server-Sent Events when the network won't let a socket open
  */
 function connectGameWebSocket() {
 	const wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-	const wsURL = `${wsProtocol}//${window.location.host}/ws/game`;
+	const wsURL = `${wsProtocol}//${window.location.host}/ws/game?${playerParams()}`;
 
 	const socket = new WebSocket(wsURL);
+	let opened = false;
 
 	socket.onopen = () => {
 		console.log('Connected to game server');
+		opened = true;
+		failedSockets = 0;
+		window.gameTransport = { send: (message) => socket.send(JSON.stringify(message)) };
+		sendMessage('hello', { protocolVersion: PROTOCOL_VERSION });
 	};
 
 	/** @param {MessageEvent} event */
 	socket.onmessage = (event) => {
 		const data = JSON.parse(event.data);
-		console.log('Received message:', data);
 		handleGameMessage(data);
 	};
 
@@ -93,86 +101,70 @@ function connectGameWebSocket() {
 
 	socket.onclose = () => {
 		console.log('Disconnected from game server');
+		window.gameTransport = null;
+		// Don't reconnect if the host removed us
+		if (window.kicked) {
+			return;
+		}
+		if (!opened && ++failedSockets >= MAX_FAILED_SOCKETS) {
+			console.warn('WebSocket unavailable, falling back to event stream');
+			connectEventStream();
+			return;
+		}
 		// Try to reconnect after 5 seconds
 		setTimeout(connectGameWebSocket, 5000);
 	};
 }
 
 /**
- * Handles game state changes from the server
- * @param {Object} state - The game state object
- * @param {string} state.state - The current game state ('waiting', 'active', 'ended')
- * @param {string} state.message - Status message to display
+ * Receives game messages over Server-Sent Events and sends requests as POSTs
  */
-function handleGameState(state) {
-	console.log('Handling game state:', state);
-
-	// Update status message
-	const statusElement = document.getElementById('gameStatus');
-	if (statusElement) {
-		statusElement.textContent = state.message || 'Game status updated';
+function connectEventStream() {
+	const source = new EventSource(`/sse/game?${playerParams()}`);
+
+	source.onopen = () => {
+		console.log('Connected to game event stream');
+		window.gameTransport = {
+			send: async (message) => {
+				const response = await fetch(`/game/message?${playerParams()}`, {
+					method: 'POST',
+					headers: { 'Content-Type': 'application/json' },
+					body: JSON.stringify(message),
+				});
+				handleGameMessage(await response.json());
+			}
+		};
+		sendMessage('hello', { protocolVersion: PROTOCOL_VERSION });
+	};
 
-		// Update styling based on game state
-		statusElement.classList.remove('text-blue-600', 'text-green-600', 'text-red-600');
-		switch (state.state) {
-			case 'active':
-				statusElement.classList.add('text-green-600');
-				break;
-			case 'ended':
-				statusElement.classList.add('text-red-600');
-				break;
-			default:
-				statusElement.classList.add('text-blue-600');
-		}
-	}
+	/** @param {MessageEvent} event */
+	source.onmessage = (event) => {
+		const data = JSON.parse(event.data);
+		handleGameMessage(data);
+	};
 
-	// Handle game container visibility
-	const questionContainer = document.getElementById('question-container');
-	if (questionContainer) {
-		questionContainer.classList.toggle('hidden', state.state !== 'active');
-	}
+	// The server sends a close event when the host removes us
+	source.addEventListener('close', () => source.close());
 
-	// Handle additional game state-specific UI updates
-	if (state.state === 'active') {
-		console.log('Game is active');
-		// Request fresh game view when game becomes active
-		// htmx.ajax('GET', '/game/view', {
-		// 	target: '#game-container',
-		// 	swap: 'innerHTML'
-		// });
-		updateGameStatus(state.state);
-		// Update player list if available
-		if (state.players) {
-			updatePlayersList(state.players);
-		}
-	} else if (state.state === 'ended') {
-		// Handle game end state
-		const gameContainer = document.getElementById('game-container');
-		if (gameContainer) {
-			gameContainer.innerHTML = '<div class="text-center p-4">Game has ended. Thank you for playing!</div>';
-		}
-	}
+	source.onerror = () => {
+		// EventSource reconnects by itself unless the stream was closed for good
+		console.error('Event stream error');
+	};
 }
 
 /**
- * Updates the game status display
- * @param {Object} status
+ * Sends a request to the server, tagged with an ID the server echoes on its ack or error
+ * @param {string} type
+ * @param {Object} payload
+ * @returns {string | null} the request ID, or null when not connected
  */
-function updateGameStatus(status) {
-	const statusElement = document.getElementById('gameStatus');
-	if (statusElement) {
-		statusElement.textContent = status.message;
-		if (status.state === 'active') {
-			statusElement.classList.remove('text-blue-600');
-			statusElement.classList.add('text-green-600');
-		}
-	}
-
-	// Show/hide question container based on game state
-	const questionContainer = document.getElementById('question-container');
-	if (questionContainer) {
-		questionContainer.classList.toggle('hidden', status.state !== 'active');
+function sendMessage(type, payload) {
+	if (!window.gameTransport) {
+		return null;
 	}
+	const id = String(nextRequestID++);
+	window.gameTransport.send({ type, id, payload });
+	return id;
 }
 
 /**
@@ -180,156 +172,81 @@ function updateGameStatus(status) {
  * @param {GameMessage} message
  */
 function handleGameMessage(message) {
-	console.log('Received message:', message);
 	switch (message.type) {
-		case 'playerJoined':
-			updatePlayersList(message.payload);
+		case 'welcome':
+			// Anonymous connections learn their IDs here, which POST requests need
+			window.playerID = message.payload.playerId;
+			window.gameID = message.payload.gameId;
+			if (message.payload.protocolVersion !== PROTOCOL_VERSION) {
+				console.warn('Server speaks protocol version', message.payload.protocolVersion);
+			}
 			break;
-		case 'gameStarted':
-			handleGameStart(message.payload);
+		case 'ack':
 			break;
-		case 'gameState':
-			handleGameState(message.payload);
+		case 'error':
+			console.error(`Request ${message.id || ''} rejected: [${message.payload.code}] ${message.payload.message}`);
 			break;
-		case 'question':
-			if (window.currentQuestion) {
-				showQuestion(message.payload.questions, window.currentQuestion, message.payload.gameId);
-
-				window.currentQuestion = window.currentQuestion + 1;
-			} else {
-				showQuestion(message.payload.questions, 0, message.payload.gameId);
-				window.currentQuestion = 1;
-			}
-
+		case 'view':
+			swapView(message.payload.html);
+			break;
+		case 'kicked':
+			window.kicked = true;
+			showKicked(message.payload.reason);
 			break;
 	}
 }
 
-
 /**
- * Updates the players list in the UI
- * @param {{ players: Object.<string, {name: string, score: number}> }} players
- */function updatePlayersList(players) {
-	console.log('Updating players list:', players);
-	const playersListElement = document.getElementById('players-list');
-	if (playersListElement) {
-		playersListElement.innerHTML = Object.values(players.players)
-			.filter(player => !player.name.startsWith('Player'))
-			.map(player => `<div class="p-2 border-b">${player.name} (${player.score})</div>`)
-			.join('');
+ * Swaps server-rendered elements into the page by id. The server owns the
+ * player screens; this is all the client does with them.
+ * @param {string} html
+ */
+function swapView(html) {
+	const template = document.createElement('template');
+	template.innerHTML = html;
+	for (const element of Array.from(template.content.children)) {
+		const target = element.id && document.getElementById(element.id);
+		if (!target) continue;
+		element.removeAttribute('hx-swap-oob');
+		target.replaceWith(element);
+		htmx.process(element);
 	}
+	startCountdowns();
 }
 
+let countdownTimer = 0;
+
 /**
- * Handles game start event
- * @param {GameData} gameData
+ * Counts down every element with data-countdown, which holds the
+ * milliseconds left when the server rendered it
  */
-function handleGameStart(gameData) {
-	console.log('Game started:', gameData);
-	// Implement game start logic
+function startCountdowns() {
+	clearInterval(countdownTimer);
+	const started = performance.now();
+	const counters = Array.from(document.querySelectorAll('[data-countdown]'));
+	if (counters.length === 0) return;
+
+	const tick = () => {
+		for (const counter of counters) {
+			const remaining = Number(/** @type {HTMLElement} */ (counter).dataset.countdown) - (performance.now() - started);
+			counter.textContent = String(Math.max(0, Math.ceil(remaining / 1000)));
+		}
+	};
+	tick();
+	countdownTimer = window.setInterval(tick, 250);
 }
-// {
-// 	ID      int      `json:"id"`
-// 	Text    string   `json:"text"`
-// 	Options []string `json:"options"`
-// 	Correct string   `json:"correct"`
-// }
 
 /**
- * Displays a question to the user
- * @param {Array<Question>} questions
- * @param {number} index
- * @param {string} gameID
+ * Replaces the player's screen with the reason they were removed
+ * @param {string} reason
  */
-function showQuestion(questions, index, gameID) {
-	const container = document.getElementById('question-container');
-	if (!container) return;
-
-	const question = questions[index];
-	if (!question) return;
-
-	// Determine if it's multiple choice or single choice
-	const isMultiple = question.type === 'multiple';
-	const inputType = isMultiple ? 'checkbox' : 'radio';
-	const inputName = `question-${question.id}`;
-
-	container.innerHTML = `
-        <div class="border p-4 rounded-lg">
-            <h3 class="text-lg font-semibold mb-4">${question.text}</h3>
-            <form
-                hx-post="/game/submit-answer"
-                hx-target="#answer-status"
-                class="space-y-2"
-            >
-                <input type="hidden" name="gameID" value="${gameID}">
-                <input type="hidden" name="questionID" value="${question.id}">
-
-                ${question.options.map((option, idx) => `
-                    <div class="flex items-center p-2 border rounded hover:bg-blue-50 transition-colors">
-                        <input
-                            type="${inputType}"
-                            id="option-${idx}"
-                            name="${inputName}"
-                            value="${option}"
-                            class="mr-2"
-                        >
-                        <label
-                            for="option-${idx}"
-                            class="flex-grow cursor-pointer"
-                        >
-                            ${option}
-                        </label>
-                    </div>
-                `).join('')}
-
-                <button
-                    type="submit"
-                    class="w-full mt-4 p-2 bg-blue-500 text-white rounded hover:bg-blue-600 transition-colors
-                           disabled:bg-gray-300 disabled:cursor-not-allowed"
-                    hx-indicator="#submit-indicator"
-                >
-                    Submit Answer
-                </button>
-            </form>
-            <div id="submit-indicator" class="htmx-indicator">
-                <div class="text-center text-gray-600">
-                    Submitting answer...
-                </div>
-            </div>
-            <div id="answer-status" class="mt-4 text-center"></div>
-        </div>
-    `;
-
-	// Add event listener for form submission
-	const form = container.querySelector('form');
-	if (form) {
-		form.addEventListener('submit', function(e) {
-			const submitButton = form.querySelector('button[type="submit"]');
-			if (submitButton) {
-				submitButton.disabled = true;
-			}
-
-			// For multiple choice, collect all selected answers
-			if (isMultiple) {
-				e.preventDefault();
-				const selectedOptions = Array.from(form.querySelectorAll(`input[name="${inputName}"]:checked`))
-					.map(input => input.value);
-
-				// Use HTMX to submit the form with multiple answers
-				htmx.ajax('POST', '/game/submit-answer', {
-					target: '#answer-status',
-					values: {
-						gameID: gameID,
-						questionID: question.id,
-						answer: selectedOptions.join(',')
-					}
-				});
-			}
-		});
-	}
-
-	container.classList.remove('hidden');
+function showKicked(reason) {
+	const view = document.getElementById('player-view');
+	if (!view) return;
+	const message = document.createElement('div');
+	message.className = 'text-center p-4 text-red-600';
+	message.textContent = reason || 'You were removed from the game.';
+	view.replaceChildren(message);
 }
 
-
 export { main };
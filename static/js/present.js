@@ -0,0 +1,117 @@
+//@ts-check
+
+/**
+ * @typedef {Object} ViewMessage
+ * @property {'view'} type
+ * @property {Object} payload
+ * @property {string} payload.html - Elements to swap in by id
+ */
+
+/**
+ * Initializes the presenter screen. The page is read-only: it follows the
+ * game over a socket and never sends anything back.
+ */
+function main() {
+	startCountdowns();
+	connect();
+}
+
+/**
+ * Opens the presenter socket, reconnecting whenever it drops
+ */
+function connect() {
+	const { gameId, token } = document.body.dataset;
+	const params = new URLSearchParams({ gameId: gameId || '', token: token || '' });
+	const wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+	const socket = new WebSocket(`${wsProtocol}//${window.location.host}/ws/present?${params}`);
+
+	/** @param {MessageEvent} event */
+	socket.onmessage = (event) => {
+		/** @type {ViewMessage} */
+		const message = JSON.parse(event.data);
+		if (message.type === 'view') {
+			swapView(message.payload.html);
+		}
+	};
+
+	socket.onclose = () => {
+		setTimeout(connect, 3000);
+	};
+}
+
+/**
+ * Swaps server-rendered elements into the page by id, sliding leaderboard
+ * rows from their old positions to their new ones
+ * @param {string} html
+ */
+function swapView(html) {
+	const before = rowPositions();
+	const template = document.createElement('template');
+	template.innerHTML = html;
+	for (const element of Array.from(template.content.children)) {
+		const target = element.id && document.getElementById(element.id);
+		if (!target) continue;
+		element.removeAttribute('hx-swap-oob');
+		target.replaceWith(element);
+	}
+	animateRows(before);
+	startCountdowns();
+}
+
+/**
+ * @returns {Map<string, number>} the top of each leaderboard row, by player
+ */
+function rowPositions() {
+	const positions = new Map();
+	document.querySelectorAll('[data-flip-key]').forEach(row => {
+		const key = /** @type {HTMLElement} */ (row).dataset.flipKey;
+		positions.set(key, row.getBoundingClientRect().top);
+	});
+	return positions;
+}
+
+/**
+ * Plays rows that moved from where they were, and fades in new ones
+ * @param {Map<string, number>} before
+ */
+function animateRows(before) {
+	document.querySelectorAll('[data-flip-key]').forEach(node => {
+		const row = /** @type {HTMLElement} */ (node);
+		const top = before.get(row.dataset.flipKey || '');
+		if (top === undefined) {
+			row.animate([{ opacity: 0 }, { opacity: 1 }], { duration: 500 });
+			return;
+		}
+		const delta = top - row.getBoundingClientRect().top;
+		if (delta !== 0) {
+			row.animate(
+				[{ transform: `translateY(${delta}px)` }, { transform: 'translateY(0)' }],
+				{ duration: 700, easing: 'ease-in-out' },
+			);
+		}
+	});
+}
+
+let countdownTimer = 0;
+
+/**
+ * Counts down every element with data-countdown, which holds the
+ * milliseconds left when the server rendered it
+ */
+function startCountdowns() {
+	clearInterval(countdownTimer);
+	const started = performance.now();
+	const counters = Array.from(document.querySelectorAll('[data-countdown]'));
+	if (counters.length === 0) return;
+
+	const tick = () => {
+		for (const counter of counters) {
+			const remaining = Number(/** @type {HTMLElement} */ (counter).dataset.countdown) - (performance.now() - started);
+			counter.textContent = String(Math.max(0, Math.ceil(remaining / 1000)));
+		}
+	};
+	tick();
+	countdownTimer = window.setInterval(tick, 250);
+}
+
+export { main };